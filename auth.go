@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/config"
+	"github.com/ajinux/pi-hole-mcp-server/tools"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+)
+
+// tokenNameKey is the TokenInfo.Extra key holding the name of the token a request authenticated with
+const tokenNameKey = "name"
+
+// newTokenVerifier returns a verifier accepting the configured bearer tokens
+func newTokenVerifier(tokens []config.AuthToken) auth.TokenVerifier {
+	// Compare digests so the comparison takes the same time whatever the token length
+	digests := make([][sha256.Size]byte, len(tokens))
+	for i, t := range tokens {
+		digests[i] = sha256.Sum256([]byte(t.Token))
+	}
+
+	return func(ctx context.Context, token string, req *http.Request) (*auth.TokenInfo, error) {
+		digest := sha256.Sum256([]byte(token))
+		match := -1
+		for i := range digests {
+			if subtle.ConstantTimeCompare(digest[:], digests[i][:]) == 1 {
+				match = i
+			}
+		}
+		if match < 0 {
+			return nil, auth.ErrInvalidToken
+		}
+		scopes := []string{tools.ScopeRead}
+		if !tokens[match].ReadOnly {
+			scopes = append(scopes, tools.ScopeWrite)
+		}
+		return &auth.TokenInfo{
+			Scopes: scopes,
+			// Static tokens do not expire, but the SDK requires an expiration
+			Expiration: time.Now().Add(time.Hour),
+			Extra:      map[string]any{tokenNameKey: tokens[match].Name},
+		}, nil
+	}
+}
+
+// requestIdentity returns who a request authenticated as: the bearer token name
+// and, with mTLS, the client certificate's common name
+func requestIdentity(r *http.Request) (tokenName, certName string) {
+	if info := auth.TokenInfoFromContext(r.Context()); info != nil {
+		tokenName, _ = info.Extra[tokenNameKey].(string)
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		certName = r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return tokenName, certName
+}
+
+// newTLSConfig returns the TLS settings of the HTTP server. Client certificates
+// are required when a client CA is configured.
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.TLSClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
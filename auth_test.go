@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ajinux/pi-hole-mcp-server/config"
+	"github.com/ajinux/pi-hole-mcp-server/tools"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+)
+
+func TestBearerTokenAuth(t *testing.T) {
+	tokens := []config.AuthToken{
+		{Name: "laptop", Token: "s3cret-laptop"},
+		{Name: "homeassistant", Token: "s3cret-ha", ReadOnly: true},
+	}
+	var gotName string
+	var gotWrite bool
+	handler := auth.RequireBearerToken(newTokenVerifier(tokens), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotName, _ = requestIdentity(r)
+		gotWrite = slices.Contains(auth.TokenInfoFromContext(r.Context()).Scopes, tools.ScopeWrite)
+	}))
+
+	tests := []struct {
+		header    string
+		wantCode  int
+		wantName  string
+		wantWrite bool
+	}{
+		{"", http.StatusUnauthorized, "", false},
+		{"Bearer wrong", http.StatusUnauthorized, "", false},
+		{"Basic s3cret-ha", http.StatusUnauthorized, "", false},
+		{"Bearer s3cret-ha", http.StatusOK, "homeassistant", false},
+		{"bearer s3cret-laptop", http.StatusOK, "laptop", true},
+	}
+	for _, tt := range tests {
+		gotName, gotWrite = "", false
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("Authorization %q: status = %d, want %d", tt.header, rec.Code, tt.wantCode)
+		}
+		if gotName != tt.wantName {
+			t.Errorf("Authorization %q: token name = %q, want %q", tt.header, gotName, tt.wantName)
+		}
+		if gotWrite != tt.wantWrite {
+			t.Errorf("Authorization %q: write scope = %v, want %v", tt.header, gotWrite, tt.wantWrite)
+		}
+	}
+}
@@ -5,24 +5,126 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// DefaultInstanceName is the name given to the Pi-hole instance configured
+// through PIHOLE_URL when PIHOLE_INSTANCES is not set
+const DefaultInstanceName = "default"
+
+// Transports the MCP server can be served over
+const (
+	TransportStdio = "stdio"
+	TransportHTTP  = "http"
+	TransportSSE   = "sse"
+)
+
+// Permission modes. A read-only server or token cannot change Pi-hole's state.
+const (
+	ModeReadOnly = "read-only"
+	ModeAdmin    = "admin"
+)
+
 // Config holds all configuration values for the application
 type Config struct {
-	PiHoleURL      string
-	PiHolePassword string
-	Port           string
+	// Instances lists the Pi-hole instances to connect to. The first one is
+	// used when a tool call does not name an instance.
+	Instances []Instance
+	Port      string
+	// Transport is how MCP clients connect: stdio, http (Streamable HTTP) or sse
+	Transport string
+	// Mode is read-only or admin. Tools that change Pi-hole's state are only
+	// available in admin mode.
+	Mode string
+	// ResourcePollInterval is how often subscribed MCP resources are checked for changes
+	ResourcePollInterval time.Duration
+
+	// AuthTokens are the bearer tokens accepted on the HTTP transports. The
+	// endpoint is unauthenticated when there are none.
+	AuthTokens []AuthToken
+	// TLSCertFile and TLSKeyFile enable HTTPS. TLSClientCAFile additionally
+	// requires clients to present a certificate signed by that CA (mTLS).
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+
+	// DNS configures the resolver used by the domain intelligence tools
+	DNS DNS
+
+	// PublicSuffixList is a public_suffix_list.dat file replacing the embedded
+	// snapshot used to find the registrable domain of a name
+	PublicSuffixList string
+}
+
+// DNS holds the settings of the resolver used for DNS record lookups
+type DNS struct {
+	// Servers are the upstreams to query in order, as host[:port] or a
+	// udp://, tcp://, tls:// (DNS-over-TLS) or https:// (DNS-over-HTTPS) URL
+	Servers []string
+	// Timeout bounds each attempt against a single server
+	Timeout time.Duration
+	// Retries is how many more times the server list is tried after all servers failed
+	Retries int
+	// Protocol is udp or tcp
+	Protocol string
+	// UDPSize is the EDNS0 buffer size, 0 disables EDNS0
+	UDPSize uint16
+	// TLSCAFile replaces the system roots for tls:// and https:// servers
+	TLSCAFile string
+	// DoHMethod is POST or GET
+	DoHMethod string
+}
+
+// AuthToken is a named bearer token. The name identifies the client in logs.
+// A read-only token cannot call tools that change Pi-hole's state.
+type AuthToken struct {
+	Name     string
+	Token    string
+	ReadOnly bool
+}
+
+// Instance holds the connection settings of a single Pi-hole
+type Instance struct {
+	Name        string
+	URL         string
+	Password    string
+	AppPassword string
+	TOTPSecret  string
 }
 
+// instanceNamePattern restricts instance names to what can be embedded in an environment variable name
+var instanceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
 // Load loads configuration from command-line flags, .env file, or environment variables
 // Priority: command-line flags > environment variables > .env file > defaults
 func Load() *Config {
 	// Define command-line flags
 	piholeURL := flag.String("pihole-url", "", "Pi-hole API URL (e.g., http://192.168.1.100/admin/api.php)")
-	piholePassword := flag.String("pihole-password", "", "Pi-hole API password (required)")
+	piholePassword := flag.String("pihole-password", "", "Pi-hole API password (required unless an app password is set)")
+	piholeAppPassword := flag.String("pihole-app-password", "", "Pi-hole application password (bypasses two-factor authentication)")
+	piholeTOTPSecret := flag.String("pihole-totp-secret", "", "Base32 TOTP secret for Pi-hole two-factor authentication")
+	piholeInstances := flag.String("pihole-instances", "", "Comma-separated names of multiple Pi-hole instances (e.g., primary,secondary)")
 	port := flag.String("port", "", "MCP server port (default: 8081)")
+	transport := flag.String("transport", "", "MCP transport: stdio, http or sse (default: http)")
+	mode := flag.String("mode", "", "Permission mode: read-only or admin (default: admin)")
+	authTokens := flag.String("auth-tokens", "", "Comma-separated name:token[:read-only|admin] bearer tokens accepted on the HTTP transports")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file, enables HTTPS")
+	tlsKey := flag.String("tls-key", "", "TLS private key file")
+	tlsClientCA := flag.String("tls-client-ca", "", "CA certificate file clients must present a certificate from (mTLS)")
+	resourcePollInterval := flag.String("resource-poll-interval", "", "How often subscribed resources are checked for changes (default: 30s)")
+	dnsServers := flag.String("dns-servers", "", "Comma-separated DNS servers used for record lookups, host[:port] or tls:// / https:// URLs (default: 1.1.1.1,8.8.8.8)")
+	dnsTimeout := flag.String("dns-timeout", "", "Timeout of a DNS query against one server (default: 5s)")
+	dnsRetries := flag.String("dns-retries", "", "How many more times the DNS servers are tried after all of them failed (default: 1)")
+	dnsProtocol := flag.String("dns-protocol", "", "DNS transport: udp or tcp (default: udp)")
+	dnsUDPSize := flag.String("dns-udp-size", "", "EDNS0 UDP buffer size, 0 disables EDNS0 (default: 1232)")
+	dnsTLSCA := flag.String("dns-tls-ca", "", "CA certificate file trusted for DNS-over-TLS and DNS-over-HTTPS servers (default: system roots)")
+	dnsDoHMethod := flag.String("dns-doh-method", "", "HTTP method for DNS-over-HTTPS: POST or GET (default: POST)")
+	publicSuffixList := flag.String("public-suffix-list", "", "Public Suffix List file used instead of the embedded snapshot")
 	// Custom usage message
 	flag.Usage = func() {
 		fmt.Printf("Usage: %s [options]\n\n", os.Args[0])
@@ -30,14 +132,72 @@ func Load() *Config {
 		fmt.Println("  --pihole-url string")
 		fmt.Println("    \tPi-hole API URL (e.g., http://192.168.1.100/api)")
 		fmt.Println("  --pihole-password string")
-		fmt.Println("    \tPi-hole API password (required)")
+		fmt.Println("    \tPi-hole API password (required unless an app password is set)")
+		fmt.Println("  --pihole-app-password string")
+		fmt.Println("    \tPi-hole application password (bypasses two-factor authentication)")
+		fmt.Println("  --pihole-totp-secret string")
+		fmt.Println("    \tBase32 TOTP secret for Pi-hole two-factor authentication")
+		fmt.Println("  --pihole-instances string")
+		fmt.Println("    \tComma-separated names of multiple Pi-hole instances (e.g., primary,secondary)")
 		fmt.Println("  --port string")
 		fmt.Println("    \tMCP server port (default: 8081)")
+		fmt.Println("  --transport string")
+		fmt.Println("    \tMCP transport: stdio, http or sse (default: http)")
+		fmt.Println("  --mode string")
+		fmt.Println("    \tPermission mode: read-only or admin (default: admin)")
+		fmt.Println("  --auth-tokens string")
+		fmt.Println("    \tComma-separated name:token[:read-only|admin] bearer tokens accepted on the HTTP transports")
+		fmt.Println("  --tls-cert string")
+		fmt.Println("    \tTLS certificate file, enables HTTPS")
+		fmt.Println("  --tls-key string")
+		fmt.Println("    \tTLS private key file")
+		fmt.Println("  --tls-client-ca string")
+		fmt.Println("    \tCA certificate file clients must present a certificate from (mTLS)")
+		fmt.Println("  --resource-poll-interval duration")
+		fmt.Println("    \tHow often subscribed resources are checked for changes (default: 30s)")
+		fmt.Println("  --dns-servers string")
+		fmt.Println("    \tComma-separated DNS servers used for record lookups, host[:port] or tls:// / https:// URLs (default: 1.1.1.1,8.8.8.8)")
+		fmt.Println("  --dns-timeout duration")
+		fmt.Println("    \tTimeout of a DNS query against one server (default: 5s)")
+		fmt.Println("  --dns-retries int")
+		fmt.Println("    \tHow many more times the DNS servers are tried after all of them failed (default: 1)")
+		fmt.Println("  --dns-protocol string")
+		fmt.Println("    \tDNS transport: udp or tcp (default: udp)")
+		fmt.Println("  --dns-udp-size int")
+		fmt.Println("    \tEDNS0 UDP buffer size, 0 disables EDNS0 (default: 1232)")
+		fmt.Println("  --dns-tls-ca string")
+		fmt.Println("    \tCA certificate file trusted for DNS-over-TLS and DNS-over-HTTPS servers (default: system roots)")
+		fmt.Println("  --dns-doh-method string")
+		fmt.Println("    \tHTTP method for DNS-over-HTTPS: POST or GET (default: POST)")
+		fmt.Println("  --public-suffix-list string")
+		fmt.Println("    \tPublic Suffix List file used instead of the embedded snapshot")
 		fmt.Println("\nConfiguration priority: command-line flags > environment variables > .env file > defaults")
 		fmt.Println("\nEnvironment variables:")
-		fmt.Println("  PIHOLE_URL         Pi-hole API URL")
-		fmt.Println("  PIHOLE_PASSWORD    Pi-hole API password (required)")
-		fmt.Println("  PORT               MCP server port")
+		fmt.Println("  PIHOLE_URL           Pi-hole API URL")
+		fmt.Println("  PIHOLE_PASSWORD      Pi-hole API password (required unless an app password is set)")
+		fmt.Println("  PIHOLE_APP_PASSWORD  Pi-hole application password")
+		fmt.Println("  PIHOLE_TOTP_SECRET   Base32 TOTP secret for two-factor authentication")
+		fmt.Println("  PIHOLE_INSTANCES     Comma-separated names of multiple Pi-hole instances")
+		fmt.Println("  PORT                 MCP server port")
+		fmt.Println("  MCP_TRANSPORT        MCP transport: stdio, http or sse")
+		fmt.Println("  MCP_MODE             Permission mode: read-only or admin")
+		fmt.Println("  MCP_AUTH_TOKENS      Comma-separated name:token[:read-only|admin] bearer tokens")
+		fmt.Println("  TLS_CERT_FILE        TLS certificate file")
+		fmt.Println("  TLS_KEY_FILE         TLS private key file")
+		fmt.Println("  TLS_CLIENT_CA_FILE   CA certificate file for client certificates (mTLS)")
+		fmt.Println("  RESOURCE_POLL_INTERVAL  How often subscribed resources are checked for changes")
+		fmt.Println("  DNS_SERVERS          Comma-separated DNS servers used for record lookups")
+		fmt.Println("  DNS_TIMEOUT          Timeout of a DNS query against one server")
+		fmt.Println("  DNS_RETRIES          How many more times the DNS servers are tried")
+		fmt.Println("  DNS_PROTOCOL         DNS transport: udp or tcp")
+		fmt.Println("  DNS_UDP_SIZE         EDNS0 UDP buffer size, 0 disables EDNS0")
+		fmt.Println("  DNS_TLS_CA_FILE      CA certificate file trusted for DNS-over-TLS/HTTPS servers")
+		fmt.Println("  DNS_DOH_METHOD       HTTP method for DNS-over-HTTPS: POST or GET")
+		fmt.Println("  PUBLIC_SUFFIX_LIST   Public Suffix List file used instead of the embedded snapshot")
+		fmt.Println("\nWhen PIHOLE_INSTANCES is set, each instance NAME is configured with")
+		fmt.Println("PIHOLE_<NAME>_URL, PIHOLE_<NAME>_PASSWORD, PIHOLE_<NAME>_APP_PASSWORD and")
+		fmt.Println("PIHOLE_<NAME>_TOTP_SECRET. Instances without credentials of their own use")
+		fmt.Println("the unprefixed ones.")
 	}
 
 	flag.Parse()
@@ -47,20 +207,211 @@ func Load() *Config {
 		log.Println("No .env file found, using environment variables or flags")
 	}
 
+	defaults := Instance{
+		Name:        DefaultInstanceName,
+		URL:         getConfigValue(*piholeURL, "PIHOLE_URL", "http://localhost:8080/api"),
+		Password:    getConfigValue(*piholePassword, "PIHOLE_PASSWORD", ""),
+		AppPassword: getConfigValue(*piholeAppPassword, "PIHOLE_APP_PASSWORD", ""),
+		TOTPSecret:  getConfigValue(*piholeTOTPSecret, "PIHOLE_TOTP_SECRET", ""),
+	}
+
 	cfg := &Config{
-		PiHoleURL:      getConfigValue(*piholeURL, "PIHOLE_URL", "http://localhost:8080/api"),
-		PiHolePassword: getConfigValue(*piholePassword, "PIHOLE_PASSWORD", ""),
-		Port:           getConfigValue(*port, "PORT", "8081"),
+		Port:      getConfigValue(*port, "PORT", "8081"),
+		Transport: strings.ToLower(getConfigValue(*transport, "MCP_TRANSPORT", TransportHTTP)),
+	}
+
+	switch cfg.Transport {
+	case TransportStdio, TransportHTTP, TransportSSE:
+	default:
+		log.Fatalf("invalid transport %q: must be %s, %s or %s", cfg.Transport, TransportStdio, TransportHTTP, TransportSSE)
+	}
+
+	cfg.Mode = strings.ToLower(getConfigValue(*mode, "MCP_MODE", ModeAdmin))
+	if cfg.Mode != ModeReadOnly && cfg.Mode != ModeAdmin {
+		log.Fatalf("invalid mode %q: must be %s or %s", cfg.Mode, ModeReadOnly, ModeAdmin)
+	}
+
+	tokens, err := parseAuthTokens(getConfigValue(*authTokens, "MCP_AUTH_TOKENS", ""))
+	if err != nil {
+		log.Fatalf("invalid auth tokens: %v", err)
+	}
+	cfg.AuthTokens = tokens
+
+	// The SSE transport does not pass token details on to tool calls, so
+	// read-only tokens could not be enforced
+	if cfg.Transport == TransportSSE {
+		for _, t := range tokens {
+			if t.ReadOnly {
+				log.Fatalf("read-only token %q is not supported with the sse transport, use http or a read-only mode", t.Name)
+			}
+		}
+	}
+
+	cfg.TLSCertFile = getConfigValue(*tlsCert, "TLS_CERT_FILE", "")
+	cfg.TLSKeyFile = getConfigValue(*tlsKey, "TLS_KEY_FILE", "")
+	cfg.TLSClientCAFile = getConfigValue(*tlsClientCA, "TLS_CLIENT_CA_FILE", "")
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		log.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		log.Fatal("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	pollInterval := getConfigValue(*resourcePollInterval, "RESOURCE_POLL_INTERVAL", "30s")
+	interval, err := time.ParseDuration(pollInterval)
+	if err != nil || interval < time.Second {
+		log.Fatalf("invalid resource poll interval %q: expected a duration of at least 1s (e.g., 30s, 5m)", pollInterval)
+	}
+	cfg.ResourcePollInterval = interval
+
+	dnsCfg, err := loadDNS(
+		getConfigValue(*dnsServers, "DNS_SERVERS", "1.1.1.1,8.8.8.8"),
+		getConfigValue(*dnsTimeout, "DNS_TIMEOUT", "5s"),
+		getConfigValue(*dnsRetries, "DNS_RETRIES", "1"),
+		getConfigValue(*dnsProtocol, "DNS_PROTOCOL", "udp"),
+		getConfigValue(*dnsUDPSize, "DNS_UDP_SIZE", "1232"),
+	)
+	if err != nil {
+		log.Fatalf("invalid DNS settings: %v", err)
+	}
+	dnsCfg.TLSCAFile = getConfigValue(*dnsTLSCA, "DNS_TLS_CA_FILE", "")
+	dnsCfg.DoHMethod = strings.ToUpper(getConfigValue(*dnsDoHMethod, "DNS_DOH_METHOD", "POST"))
+	if dnsCfg.DoHMethod != "POST" && dnsCfg.DoHMethod != "GET" {
+		log.Fatalf("invalid DNS settings: DoH method %q: must be POST or GET", dnsCfg.DoHMethod)
+	}
+	cfg.DNS = dnsCfg
+	cfg.PublicSuffixList = getConfigValue(*publicSuffixList, "PUBLIC_SUFFIX_LIST", "")
+
+	names := getConfigValue(*piholeInstances, "PIHOLE_INSTANCES", "")
+	if names == "" {
+		cfg.Instances = []Instance{defaults}
+	} else {
+		seen := make(map[string]bool)
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !instanceNamePattern.MatchString(name) {
+				log.Fatalf("invalid Pi-hole instance name %q: only letters, digits and underscores are allowed", name)
+			}
+			if strings.EqualFold(name, "all") {
+				log.Fatal(`"all" is reserved and cannot be used as a Pi-hole instance name`)
+			}
+			if seen[strings.ToLower(name)] {
+				log.Fatalf("duplicate Pi-hole instance name %q", name)
+			}
+			seen[strings.ToLower(name)] = true
+			cfg.Instances = append(cfg.Instances, loadInstance(name, defaults))
+		}
 	}
 
 	// Validate required fields
-	if cfg.PiHolePassword == "" {
-		log.Fatal("PIHOLE_PASSWORD is required (set via --pihole-password flag or PIHOLE_PASSWORD environment variable)")
+	for _, inst := range cfg.Instances {
+		if inst.URL == "" {
+			log.Fatalf("PIHOLE_%s_URL is required for Pi-hole instance %q", strings.ToUpper(inst.Name), inst.Name)
+		}
+		if inst.Password == "" && inst.AppPassword == "" {
+			log.Fatalf("PIHOLE_PASSWORD or PIHOLE_APP_PASSWORD is required for Pi-hole instance %q (set via --pihole-password/--pihole-app-password flags or environment variables)", inst.Name)
+		}
 	}
 
 	return cfg
 }
 
+// loadInstance reads the PIHOLE_<NAME>_* settings of a named instance. Instances
+// without any credentials of their own use the unprefixed ones.
+func loadInstance(name string, defaults Instance) Instance {
+	prefix := "PIHOLE_" + strings.ToUpper(name) + "_"
+	inst := Instance{
+		Name:        name,
+		URL:         os.Getenv(prefix + "URL"),
+		Password:    os.Getenv(prefix + "PASSWORD"),
+		AppPassword: os.Getenv(prefix + "APP_PASSWORD"),
+		TOTPSecret:  os.Getenv(prefix + "TOTP_SECRET"),
+	}
+	if inst.Password == "" && inst.AppPassword == "" && inst.TOTPSecret == "" {
+		inst.Password = defaults.Password
+		inst.AppPassword = defaults.AppPassword
+		inst.TOTPSecret = defaults.TOTPSecret
+	}
+	return inst
+}
+
+// parseAuthTokens parses a comma-separated list of name:token pairs, each
+// optionally followed by :read-only or :admin (the default)
+func parseAuthTokens(value string) ([]AuthToken, error) {
+	var tokens []AuthToken
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(entry, ":")
+		readOnly := false
+		if i := strings.LastIndex(token, ":"); i >= 0 {
+			switch strings.ToLower(token[i+1:]) {
+			case ModeReadOnly:
+				readOnly = true
+				token = token[:i]
+			case ModeAdmin:
+				token = token[:i]
+			}
+		}
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("expected name:token[:read-only|admin], got %q", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate token name %q", name)
+		}
+		seen[name] = true
+		tokens = append(tokens, AuthToken{Name: name, Token: token, ReadOnly: readOnly})
+	}
+	return tokens, nil
+}
+
+// loadDNS parses the DNS resolver settings
+func loadDNS(servers, timeout, retries, protocol, udpSize string) (DNS, error) {
+	var dns DNS
+	for _, server := range strings.Split(servers, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			dns.Servers = append(dns.Servers, server)
+		}
+	}
+	if len(dns.Servers) == 0 {
+		return dns, fmt.Errorf("at least one DNS server is required")
+	}
+
+	var err error
+	if dns.Timeout, err = time.ParseDuration(timeout); err != nil || dns.Timeout <= 0 {
+		return dns, fmt.Errorf("timeout %q: expected a positive duration (e.g., 5s, 500ms)", timeout)
+	}
+	if dns.Retries, err = strconv.Atoi(retries); err != nil || dns.Retries < 0 {
+		return dns, fmt.Errorf("retries %q: expected a number of at least 0", retries)
+	}
+	dns.Protocol = strings.ToLower(protocol)
+	if dns.Protocol != "udp" && dns.Protocol != "tcp" {
+		return dns, fmt.Errorf("protocol %q: must be udp or tcp", protocol)
+	}
+	size, err := strconv.ParseUint(udpSize, 10, 16)
+	if err != nil || (size != 0 && size < 512) {
+		return dns, fmt.Errorf("UDP size %q: expected 0 or a size between 512 and 65535", udpSize)
+	}
+	dns.UDPSize = uint16(size)
+	return dns, nil
+}
+
+// Credentials returns the password to log in with and the TOTP secret, if any.
+// An application password takes precedence since it is not subject to 2FA.
+func (i Instance) Credentials() (password, totpSecret string) {
+	if i.AppPassword != "" {
+		return i.AppPassword, ""
+	}
+	return i.Password, i.TOTPSecret
+}
+
 // getConfigValue gets a configuration value with priority: flag > env var > default
 func getConfigValue(flagValue, envKey, defaultValue string) string {
 	// Priority 1: Command-line flag
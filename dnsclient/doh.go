@@ -0,0 +1,79 @@
+package dnsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/miekg/dns"
+)
+
+// dohMediaType is the media type of DNS messages in DNS-over-HTTPS (RFC 8484)
+const dohMediaType = "application/dns-message"
+
+// exchangeHTTPS sends m to a DNS-over-HTTPS server in wire format, as the body
+// of a POST or in the dns query parameter of a GET
+func (r *Resolver) exchangeHTTPS(ctx context.Context, m *dns.Msg, server *upstream) (*dns.Msg, error) {
+	query := m.Copy()
+	if r.dohMethod == DoHMethodGet {
+		// RFC 8484 recommends ID 0 so that GET responses can be cached
+		query.Id = 0
+	}
+	wire, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack query: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	endpoint := *server.endpoint
+	var body io.Reader
+	if r.dohMethod == DoHMethodGet {
+		params := endpoint.Query()
+		params.Set("dns", base64.RawURLEncoding.EncodeToString(wire))
+		endpoint.RawQuery = params.Encode()
+	} else {
+		body = bytes.NewReader(wire)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.dohMethod, endpoint.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", dohMediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", dohMediaType)
+	}
+
+	resp, err := server.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != dohMediaType {
+		return nil, fmt.Errorf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	// A DNS message is at most 64 KiB
+	data, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > dns.MaxMsgSize {
+		return nil, fmt.Errorf("response exceeds %d bytes", dns.MaxMsgSize)
+	}
+
+	answer := new(dns.Msg)
+	if err := answer.Unpack(data); err != nil {
+		return nil, fmt.Errorf("failed to unpack response: %w", err)
+	}
+	answer.Id = m.Id
+	return answer, nil
+}
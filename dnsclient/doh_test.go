@@ -0,0 +1,139 @@
+package dnsclient
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// writeCA writes the certificate of srv to a PEM file and returns its path
+func writeCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// startDoH serves zone over DNS-over-HTTPS on /dns-query and records the request methods
+func startDoH(t *testing.T, methods *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/dns-query" || req.Header.Get("Accept") != dohMediaType {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		*methods = append(*methods, req.Method)
+
+		var wire []byte
+		var err error
+		if req.Method == http.MethodGet {
+			wire, err = base64.RawURLEncoding.DecodeString(req.URL.Query().Get("dns"))
+		} else {
+			wire, err = io.ReadAll(req.Body)
+		}
+		query := new(dns.Msg)
+		if err == nil {
+			err = query.Unpack(wire)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data, _ := answer(query).Pack()
+		w.Header().Set("Content-Type", dohMediaType)
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestResolverDoH(t *testing.T) {
+	var methods []string
+	srv := startDoH(t, &methods)
+	ca := writeCA(t, srv)
+
+	for _, method := range []string{DoHMethodPost, DoHMethodGet} {
+		t.Run(method, func(t *testing.T) {
+			methods = nil
+			r := newTestResolver(t, Options{Servers: []string{srv.URL + "/dns-query"}, TLSCAFile: ca, DoHMethod: method})
+			records, err := lookupA(r, "example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 || records[0] != "192.0.2.1" {
+				t.Errorf("got %v, want [192.0.2.1]", records)
+			}
+			if len(methods) != 1 || methods[0] != method {
+				t.Errorf("server saw %v, want a single %s", methods, method)
+			}
+		})
+	}
+
+	t.Run("nxdomain", func(t *testing.T) {
+		r := newTestResolver(t, Options{Servers: []string{srv.URL + "/dns-query"}, TLSCAFile: ca})
+		if _, err := r.Lookup(context.Background(), "missing.test", dns.TypeA); err != ErrNXDomain {
+			t.Errorf("got %v, want ErrNXDomain", err)
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		r := newTestResolver(t, Options{Servers: []string{srv.URL + "/dns-query"}})
+		if _, err := r.Lookup(context.Background(), "example.com", dns.TypeA); err == nil {
+			t.Error("expected the self-signed certificate to be rejected without the CA file")
+		}
+	})
+}
+
+func TestResolverDoT(t *testing.T) {
+	// Borrow the test certificate, valid for 127.0.0.1 and example.com
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certSrv.Close()
+	ca := writeCA(t, certSrv)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certSrv.TLS.Certificates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	dotSrv := &dns.Server{Listener: l, Net: "tcp-tls", Handler: dns.HandlerFunc(zone), NotifyStartedFunc: func() { close(started) }}
+	go dotSrv.ActivateAndServe()
+	<-started
+	defer dotSrv.Shutdown()
+	addr := l.Addr().String()
+
+	for server, ok := range map[string]bool{
+		"tls://" + addr:                  true,
+		"tls://" + addr + "#example.com": true,
+		"tls://" + addr + "#wrong.test":  false,
+	} {
+		t.Run(server, func(t *testing.T) {
+			r := newTestResolver(t, Options{Servers: []string{server}, TLSCAFile: ca})
+			records, err := lookupA(r, "example.com")
+			if !ok {
+				if err == nil {
+					t.Error("expected the certificate not to match the server name")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 || records[0] != "192.0.2.1" {
+				t.Errorf("got %v, want [192.0.2.1]", records)
+			}
+		})
+	}
+}
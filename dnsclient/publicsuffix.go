@@ -0,0 +1,130 @@
+package dnsclient
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// idnaProfile converts names to lower-case ASCII like idna.Lookup, but allows
+// underscores, which are common in names such as _dmarc.example.com
+var idnaProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.StrictDomainName(false))
+
+// DomainName is a domain name split at its registrable domain
+type DomainName struct {
+	// Name is the name in lower-case ASCII (punycode), without a trailing dot
+	Name string
+	// Unicode is Name with its punycode labels decoded
+	Unicode string
+	// PublicSuffix is the suffix under which names are registered, e.g. co.uk
+	PublicSuffix string
+	// Registrable is the public suffix plus one label (eTLD+1), e.g. bbc.co.uk
+	Registrable string
+}
+
+// SuffixList computes public suffixes from Public Suffix List rules.
+// A nil *SuffixList uses the snapshot embedded in golang.org/x/net/publicsuffix.
+type SuffixList struct {
+	rules      map[string]bool
+	wildcards  map[string]bool
+	exceptions map[string]bool
+}
+
+// LoadSuffixList reads a list in the format of https://publicsuffix.org/list/public_suffix_list.dat,
+// for running with a newer list than the embedded snapshot
+func LoadSuffixList(path string) (*SuffixList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open public suffix list: %w", err)
+	}
+	defer f.Close()
+
+	l := &SuffixList{
+		rules:      make(map[string]bool),
+		wildcards:  make(map[string]bool),
+		exceptions: make(map[string]bool),
+	}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		// Rules are the first word of a line, comments start with //
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+		rule := fields[0]
+
+		target := l.rules
+		switch {
+		case strings.HasPrefix(rule, "!"):
+			target, rule = l.exceptions, rule[1:]
+		case strings.HasPrefix(rule, "*."):
+			target, rule = l.wildcards, rule[2:]
+		}
+		ascii, err := idnaProfile.ToASCII(rule)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rule %q: %w", path, line, fields[0], err)
+		}
+		target[ascii] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read public suffix list: %w", err)
+	}
+	if len(l.rules)+len(l.wildcards) == 0 {
+		return nil, fmt.Errorf("no rules found in public suffix list %s", path)
+	}
+	return l, nil
+}
+
+// PublicSuffix returns the public suffix of name, which must be in lower-case
+// ASCII. Names that match no rule have their last label as public suffix.
+func (l *SuffixList) PublicSuffix(name string) string {
+	if l == nil {
+		suffix, _ := publicsuffix.PublicSuffix(name)
+		return suffix
+	}
+
+	// The first match from the longest candidate is the most specific rule
+	labels := strings.Split(name, ".")
+	for i := range labels {
+		candidate := strings.Join(labels[i:], ".")
+		if l.exceptions[candidate] {
+			return strings.Join(labels[i+1:], ".")
+		}
+		if l.rules[candidate] {
+			return candidate
+		}
+		if i+1 < len(labels) && l.wildcards[strings.Join(labels[i+1:], ".")] {
+			return candidate
+		}
+	}
+	return labels[len(labels)-1]
+}
+
+// ParseDomain normalizes name to lower-case ASCII and determines its public
+// suffix and registrable domain. Public suffixes themselves, like co.uk, have
+// no registrable domain and are rejected.
+func (l *SuffixList) ParseDomain(name string) (DomainName, error) {
+	ascii, err := idnaProfile.ToASCII(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	if err != nil {
+		return DomainName{}, fmt.Errorf("invalid domain %q: %w", name, err)
+	}
+	if ascii == "" || strings.Contains(ascii, "..") {
+		return DomainName{}, fmt.Errorf("invalid domain %q", name)
+	}
+
+	d := DomainName{Name: ascii, PublicSuffix: l.PublicSuffix(ascii)}
+	if d.Unicode, err = idna.ToUnicode(ascii); err != nil {
+		d.Unicode = ascii
+	}
+	if d.PublicSuffix == ascii {
+		return d, fmt.Errorf("%s is a public suffix, not a registrable domain", ascii)
+	}
+	// Keep the label left of the public suffix
+	rest := strings.TrimSuffix(ascii, "."+d.PublicSuffix)
+	d.Registrable = rest[strings.LastIndex(rest, ".")+1:] + "." + d.PublicSuffix
+	return d, nil
+}
@@ -0,0 +1,87 @@
+package dnsclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDomain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "public_suffix_list.dat")
+	list := `// ===BEGIN ICANN DOMAINS===
+com
+uk
+co.uk
+jp
+*.kawasaki.jp
+!city.kawasaki.jp
+// Unicode rules are converted to punycode
+公司.cn
+
+// ===BEGIN PRIVATE DOMAINS===
+github.io
+`
+	if err := os.WriteFile(path, []byte(list), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := LoadSuffixList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		registrable string
+		suffix      string
+		wantErr     bool
+	}{
+		{name: "second level", input: "www.example.com", registrable: "example.com", suffix: "com"},
+		{name: "multi-label suffix", input: "news.bbc.co.uk", registrable: "bbc.co.uk", suffix: "co.uk"},
+		{name: "private suffix", input: "foo.github.io", registrable: "foo.github.io", suffix: "github.io"},
+		{name: "wildcard", input: "www.shop.foo.kawasaki.jp", registrable: "shop.foo.kawasaki.jp", suffix: "foo.kawasaki.jp"},
+		{name: "exception", input: "www.city.kawasaki.jp", registrable: "city.kawasaki.jp", suffix: "kawasaki.jp"},
+		{name: "case and trailing dot", input: "WWW.Example.COM.", registrable: "example.com", suffix: "com"},
+		{name: "underscore label", input: "_dmarc.example.com", registrable: "example.com", suffix: "com"},
+		{name: "public suffix", input: "co.uk", wantErr: true},
+		{name: "empty label", input: "www..example.com", wantErr: true},
+	}
+	for _, list := range []struct {
+		name string
+		l    *SuffixList
+	}{{"embedded", nil}, {"file", fromFile}} {
+		for _, tt := range tests {
+			t.Run(list.name+"/"+tt.name, func(t *testing.T) {
+				d, err := list.l.ParseDomain(tt.input)
+				if tt.wantErr {
+					if err == nil {
+						t.Errorf("ParseDomain(%q) = %+v, want an error", tt.input, d)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("ParseDomain(%q): %v", tt.input, err)
+				}
+				if d.Registrable != tt.registrable || d.PublicSuffix != tt.suffix {
+					t.Errorf("ParseDomain(%q) = %s under %s, want %s under %s", tt.input, d.Registrable, d.PublicSuffix, tt.registrable, tt.suffix)
+				}
+			})
+		}
+	}
+
+	t.Run("IDN", func(t *testing.T) {
+		d, err := fromFile.ParseDomain("Bücher.公司.cn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := DomainName{
+			Name:         "xn--bcher-kva.xn--55qx5d.cn",
+			Unicode:      "bücher.公司.cn",
+			PublicSuffix: "xn--55qx5d.cn",
+			Registrable:  "xn--bcher-kva.xn--55qx5d.cn",
+		}
+		if d != want {
+			t.Errorf("got %+v, want %+v", d, want)
+		}
+	})
+}
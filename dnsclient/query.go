@@ -1,128 +1,230 @@
 package dnsclient
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/miekg/dns"
 )
 
-// ExtractTopLevelDomain strips subdomain prefixes and returns the top-level domain
-// Example: api.example.com -> example.com, www.subdomain.example.com -> example.com
-func ExtractTopLevelDomain(domain string) string {
-	// Remove trailing dot if present
-	domain = strings.TrimSuffix(domain, ".")
-
-	// Split by dots
-	parts := strings.Split(domain, ".")
+// maxCNAMEChain bounds how many CNAMEs are followed from a name
+const maxCNAMEChain = 8
+
+// fetch looks up the records of type qtype for name, following CNAMEs unless
+// CNAMEs are looked up. It returns the CNAME chain leading from name to the
+// records, which is also returned when the chain ends at a name that does not exist.
+func (r *Resolver) fetch(ctx context.Context, name string, qtype uint16) ([]CNAME, []dns.RR, error) {
+	var chain []CNAME
+	target := dns.Fqdn(name)
+	for {
+		queried := target
+		answer, err := r.Lookup(ctx, queried, qtype)
+
+		// Recursive servers answer with the chain they followed, even for NXDOMAIN
+		for next := qtype != dns.TypeCNAME; next; {
+			next = false
+			for _, rr := range answer {
+				if c, ok := rr.(*dns.CNAME); ok && strings.EqualFold(c.Hdr.Name, target) {
+					chain = append(chain, CNAME{Name: hostName(c.Hdr.Name), Target: hostName(c.Target), TTL: c.Hdr.Ttl})
+					target, next = c.Target, true
+					break
+				}
+			}
+			if len(chain) > maxCNAMEChain {
+				return chain, nil, fmt.Errorf("CNAME chain of %s is longer than %d", name, maxCNAMEChain)
+			}
+		}
+		if err != nil {
+			return chain, nil, err
+		}
 
-	// If already a TLD or invalid, return as-is
-	if len(parts) <= 2 {
-		return domain
+		var records []dns.RR
+		for _, rr := range answer {
+			if rr.Header().Rrtype == qtype && strings.EqualFold(rr.Header().Name, target) {
+				records = append(records, rr)
+			}
+		}
+		// Resolve the end of a chain the server did not follow itself
+		if len(records) > 0 || strings.EqualFold(target, queried) {
+			return chain, records, nil
+		}
 	}
+}
 
-	// Return last two parts (domain.tld)
-	return strings.Join(parts[len(parts)-2:], ".")
+// DNSRecord holds the records found for a name. Record types that were not
+// looked up, or whose lookup failed, are nil.
+type DNSRecord struct {
+	Domain string
+	// Name is the name the A, AAAA, CNAME, SRV, HTTPS and SVCB records were
+	// looked up for, and CNAMEChain the CNAMEs it is an alias through
+	Name       string
+	CNAMEChain []CNAME
+	// Types are the record types that were looked up
+	Types []uint16
+
+	A      []AddressRecord
+	AAAA   []AddressRecord
+	CNAME  []CNAME
+	NS     []NSRecord
+	MX     []MXRecord
+	TXT    []TXTRecord
+	SOA    []SOARecord
+	CAA    []CAARecord
+	SRV    []SRVRecord
+	HTTPS  []SVCBRecord
+	SVCB   []SVCBRecord
+	DS     []DSRecord
+	DNSKEY []DNSKEYRecord
+	PTR    []PTRRecord
+
+	// Errors holds the lookups that failed, by record type
+	Errors map[string]string
 }
 
-func query(domain string, qtype uint16) ([]dns.RR, error) {
-	c := dns.Client{}
-	m := dns.Msg{}
-	m.SetQuestion(dns.Fqdn(domain), qtype)
+// empty reports whether no records were found
+func (record *DNSRecord) empty() bool {
+	return len(record.CNAMEChain)+len(record.A)+len(record.AAAA)+len(record.CNAME)+
+		len(record.NS)+len(record.MX)+len(record.TXT)+len(record.SOA)+len(record.CAA)+
+		len(record.SRV)+len(record.HTTPS)+len(record.SVCB)+len(record.DS)+len(record.DNSKEY) == 0
+}
 
-	// Use a public resolver. Can use 1.1.1.1, 8.8.8.8, or your own.
-	resp, _, err := c.Exchange(&m, "1.1.1.1:53")
-	if err != nil {
-		return nil, err
-	}
-	if resp.Rcode != dns.RcodeSuccess {
-		return nil, fmt.Errorf("bad rcode: %d", resp.Rcode)
-	}
-	return resp.Answer, nil
+// nameTypes are looked up for the exact name, the other types at the domain
+var nameTypes = map[uint16]bool{
+	dns.TypeA: true, dns.TypeAAAA: true, dns.TypeCNAME: true,
+	dns.TypeSRV: true, dns.TypeHTTPS: true, dns.TypeSVCB: true,
 }
 
-func fetchA(domain string) []string {
-	records, _ := query(domain, dns.TypeA)
-	var ips []string
-	for _, rr := range records {
-		if a, ok := rr.(*dns.A); ok {
-			ips = append(ips, a.A.String())
-		}
+// GetAllRecords looks up the records of the given types, all of RecordTypes if
+// none are given. A, AAAA, CNAME, SRV, HTTPS and SVCB records and the CNAME
+// chain are looked up for name, the other types at domain, usually the
+// registrable domain of name. PTR looks up the reverse names of the A and AAAA
+// records, which are looked up as well. Lookups that fail are reported in
+// Errors; an error is only returned when no name exists or every lookup failed.
+func (r *Resolver) GetAllRecords(ctx context.Context, name, domain string, qtypes ...uint16) (DNSRecord, error) {
+	record := DNSRecord{Domain: domain, Name: name}
+	if len(qtypes) == 0 {
+		qtypes = RecordTypes
 	}
-	return ips
-}
 
-func fetchAAAA(domain string) []string {
-	records, _ := query(domain, dns.TypeAAAA)
-	var ips []string
-	for _, rr := range records {
-		if a, ok := rr.(*dns.AAAA); ok {
-			ips = append(ips, a.AAAA.String())
+	requested := make(map[uint16]bool, len(qtypes))
+	for _, qtype := range qtypes {
+		requested[qtype] = true
+	}
+	// PTR lookups follow from the addresses found, after the other lookups
+	reverse := requested[dns.TypePTR]
+	var lookups []uint16
+	for _, qtype := range RecordTypes {
+		if qtype == dns.TypePTR {
+			continue
+		}
+		if requested[qtype] || (reverse && (qtype == dns.TypeA || qtype == dns.TypeAAAA)) {
+			lookups = append(lookups, qtype)
 		}
 	}
-	return ips
-}
 
-func fetchNS(domain string) []string {
-	records, _ := query(domain, dns.TypeNS)
-	var nss []string
-	for _, rr := range records {
-		if ns, ok := rr.(*dns.NS); ok {
-			nss = append(nss, ns.Ns)
-		}
+	record.Types = lookups
+	if reverse {
+		record.Types = append(slices.Clip(lookups), dns.TypePTR)
 	}
-	return nss
-}
 
-func fetchMX(domain string) []string {
-	records, _ := query(domain, dns.TypeMX)
-	var mxs []string
-	for _, rr := range records {
-		if mx, ok := rr.(*dns.MX); ok {
-			mxs = append(mxs, fmt.Sprintf("%s (pref %d)", mx.Mx, mx.Preference))
+	// Query all record types concurrently
+	errs := make([]error, len(lookups))
+	chains := make([][]CNAME, len(lookups))
+	results := make([][]dns.RR, len(lookups))
+	var wg sync.WaitGroup
+	for i, qtype := range lookups {
+		lookupName := domain
+		if nameTypes[qtype] {
+			lookupName = name
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chains[i], results[i], errs[i] = r.fetch(ctx, lookupName, qtype)
+		}()
+	}
+	wg.Wait()
+
+	failed, nxdomain := 0, 0
+	for i, err := range errs {
+		qtype := lookups[i]
+		if err == nil {
+			record.addRecords(qtype, results[i])
+			continue
+		}
+		failed++
+		if errors.Is(err, ErrNXDomain) {
+			nxdomain++
+		}
+		if record.Errors == nil {
+			record.Errors = make(map[string]string)
+		}
+		record.Errors[dns.TypeToString[qtype]] = err.Error()
+	}
+	// The lookups for name follow the same chain, also when it ends at a missing name
+	for i, qtype := range lookups {
+		if nameTypes[qtype] && len(chains[i]) > len(record.CNAMEChain) {
+			record.CNAMEChain = chains[i]
 		}
 	}
-	return mxs
-}
 
-func fetchTXT(domain string) []string {
-	records, _ := query(domain, dns.TypeTXT)
-	var txts []string
-	for _, rr := range records {
-		if txt, ok := rr.(*dns.TXT); ok {
-			txts = append(txts, strings.Join(txt.Txt, " "))
+	if reverse {
+		if err := r.lookupPTR(ctx, &record); err != nil {
+			if record.Errors == nil {
+				record.Errors = make(map[string]string)
+			}
+			record.Errors["PTR"] = err.Error()
 		}
 	}
-	return txts
-}
 
-type DNSRecord struct {
-	Domain string
-	A      []string
-	AAAA   []string
-	NS     []string
-	MX     []string
-	TXT    []string
+	switch {
+	case len(lookups) > 0 && nxdomain == len(lookups):
+		return record, fmt.Errorf("%w: %s", ErrNXDomain, name)
+	case len(lookups) > 0 && failed == len(lookups):
+		return record, fmt.Errorf("all DNS lookups for %s failed: %w", name, errors.Join(errs...))
+	case len(record.Errors) == 0 && record.empty():
+		// Check if domain exists (at least one record type should have data)
+		return record, fmt.Errorf("no such domain exists: %s", name)
+	}
+	return record, nil
 }
 
-func GetAllRecords(domain string) (DNSRecord, error) {
-	// Strip to top-level domain
-	tld := ExtractTopLevelDomain(domain)
-
-	// Query all record types
-	record := DNSRecord{
-		Domain: tld,
-		A:      fetchA(tld),
-		AAAA:   fetchAAAA(tld),
-		NS:     fetchNS(tld),
-		MX:     fetchMX(tld),
-		TXT:    fetchTXT(tld),
+// lookupPTR looks up the reverse names of the addresses in record. Addresses
+// without a reverse name are left out.
+func (r *Resolver) lookupPTR(ctx context.Context, record *DNSRecord) error {
+	addrs := record.addresses()
+	results := make([][]PTRRecord, len(addrs))
+	errs := make([]error, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		arpa, err := dns.ReverseAddr(addr)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, rrs, err := r.fetch(ctx, arpa, dns.TypePTR)
+			if err != nil && !errors.Is(err, ErrNXDomain) {
+				errs[i] = fmt.Errorf("%s: %w", addr, err)
+			}
+			for _, rr := range rrs {
+				if ptr, ok := rr.(*dns.PTR); ok {
+					results[i] = append(results[i], PTRRecord{Address: addr, Host: hostName(ptr.Ptr), TTL: ptr.Hdr.Ttl})
+				}
+			}
+		}()
 	}
+	wg.Wait()
 
-	// Check if domain exists (at least one record type should have data)
-	if len(record.A) == 0 && len(record.AAAA) == 0 && len(record.NS) == 0 && len(record.MX) == 0 && len(record.TXT) == 0 {
-		return record, fmt.Errorf("no such domain exists: %s", tld)
+	record.PTR = []PTRRecord{}
+	for _, ptrs := range results {
+		record.PTR = append(record.PTR, ptrs...)
 	}
-
-	return record, nil
+	return errors.Join(errs...)
 }
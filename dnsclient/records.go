@@ -0,0 +1,273 @@
+package dnsclient
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// RecordTypes are the record types GetAllRecords can look up, in the order they are reported
+var RecordTypes = []uint16{
+	dns.TypeA, dns.TypeAAAA, dns.TypeCNAME, dns.TypeNS, dns.TypeMX, dns.TypeTXT, dns.TypeSOA,
+	dns.TypeCAA, dns.TypeSRV, dns.TypeHTTPS, dns.TypeSVCB, dns.TypeDS, dns.TypeDNSKEY, dns.TypePTR,
+}
+
+// CNAME is a link of a CNAME chain
+type CNAME struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	TTL    uint32 `json:"ttl"`
+}
+
+// AddressRecord is an A or AAAA record
+type AddressRecord struct {
+	Address string `json:"address"`
+	TTL     uint32 `json:"ttl"`
+}
+
+// NSRecord is a name server of a zone
+type NSRecord struct {
+	Host string `json:"host"`
+	TTL  uint32 `json:"ttl"`
+}
+
+// MXRecord is a mail server of a domain, lower preferences are tried first
+type MXRecord struct {
+	Host       string `json:"host"`
+	Preference uint16 `json:"preference"`
+	TTL        uint32 `json:"ttl"`
+}
+
+// TXTRecord is a TXT record with its strings joined, as SPF and DMARC read them
+type TXTRecord struct {
+	Text string `json:"text"`
+	TTL  uint32 `json:"ttl"`
+}
+
+// SOARecord is the start of authority of a zone
+type SOARecord struct {
+	PrimaryNS string `json:"primary_ns"`
+	// AdminEmail is the mailbox of the SOA RNAME, e.g. hostmaster@example.com
+	AdminEmail string `json:"admin_email"`
+	Serial     uint32 `json:"serial"`
+	Refresh    uint32 `json:"refresh"`
+	Retry      uint32 `json:"retry"`
+	Expire     uint32 `json:"expire"`
+	MinTTL     uint32 `json:"min_ttl"`
+	TTL        uint32 `json:"ttl"`
+}
+
+// CAARecord restricts which certificate authorities may issue for a domain
+type CAARecord struct {
+	Flag  uint8  `json:"flag"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+	TTL   uint32 `json:"ttl"`
+}
+
+// SRVRecord locates a service, for names like _sip._tcp.example.com
+type SRVRecord struct {
+	Target   string `json:"target"`
+	Port     uint16 `json:"port"`
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	TTL      uint32 `json:"ttl"`
+}
+
+// SVCBRecord is an HTTPS or SVCB record. Priority 0 marks an alias to Target.
+type SVCBRecord struct {
+	Priority uint16   `json:"priority"`
+	Target   string   `json:"target"`
+	ALPN     []string `json:"alpn,omitempty"`
+	Port     uint16   `json:"port,omitempty"`
+	// ECH is whether an Encrypted ClientHello configuration is published
+	ECH      bool     `json:"ech"`
+	IPv4Hint []string `json:"ipv4_hint,omitempty"`
+	IPv6Hint []string `json:"ipv6_hint,omitempty"`
+	// Params holds the other parameters in presentation format
+	Params map[string]string `json:"params,omitempty"`
+	TTL    uint32            `json:"ttl"`
+}
+
+// DSRecord is a delegation signer in the parent zone, its presence means the zone is signed
+type DSRecord struct {
+	KeyTag     uint16 `json:"key_tag"`
+	Algorithm  string `json:"algorithm"`
+	DigestType uint8  `json:"digest_type"`
+	Digest     string `json:"digest"`
+	TTL        uint32 `json:"ttl"`
+}
+
+// DNSKEYRecord is a DNSSEC key of a zone, without the key material
+type DNSKEYRecord struct {
+	KeyTag    uint16 `json:"key_tag"`
+	Algorithm string `json:"algorithm"`
+	Flags     uint16 `json:"flags"`
+	// SecureEntryPoint marks key signing keys
+	SecureEntryPoint bool   `json:"secure_entry_point"`
+	TTL              uint32 `json:"ttl"`
+}
+
+// PTRRecord is the reverse DNS name of an address found by the A or AAAA lookup
+type PTRRecord struct {
+	Address string `json:"address"`
+	Host    string `json:"host"`
+	TTL     uint32 `json:"ttl"`
+}
+
+// hostName returns a domain name without the trailing dot, as host names are
+// written in all records
+func hostName(name string) string {
+	if name == "." {
+		return name
+	}
+	return strings.TrimSuffix(name, ".")
+}
+
+// mailbox converts an SOA RNAME to an email address: the first label not
+// ending in an escaped dot is the local part
+func mailbox(rname string) string {
+	rname = hostName(rname)
+	for i := 0; i < len(rname); i++ {
+		switch rname[i] {
+		case '\\':
+			i++
+		case '.':
+			return strings.ReplaceAll(rname[:i], `\.`, ".") + "@" + rname[i+1:]
+		}
+	}
+	return rname
+}
+
+// newSVCBRecord converts the fields of an HTTPS or SVCB record
+func newSVCBRecord(rr *dns.SVCB) SVCBRecord {
+	record := SVCBRecord{Priority: rr.Priority, Target: hostName(rr.Target), TTL: rr.Hdr.Ttl}
+	for _, kv := range rr.Value {
+		switch kv := kv.(type) {
+		case *dns.SVCBAlpn:
+			record.ALPN = kv.Alpn
+		case *dns.SVCBPort:
+			record.Port = kv.Port
+		case *dns.SVCBECHConfig:
+			record.ECH = len(kv.ECH) > 0
+		case *dns.SVCBIPv4Hint:
+			for _, ip := range kv.Hint {
+				record.IPv4Hint = append(record.IPv4Hint, ip.String())
+			}
+		case *dns.SVCBIPv6Hint:
+			for _, ip := range kv.Hint {
+				record.IPv6Hint = append(record.IPv6Hint, ip.String())
+			}
+		default:
+			if record.Params == nil {
+				record.Params = make(map[string]string)
+			}
+			record.Params[kv.Key().String()] = kv.String()
+		}
+	}
+	return record
+}
+
+// addRecords stores rrs in the field of record for their type
+func (record *DNSRecord) addRecords(qtype uint16, rrs []dns.RR) {
+	// Successful lookups without records are reported as empty lists
+	switch qtype {
+	case dns.TypeA:
+		record.A = []AddressRecord{}
+	case dns.TypeAAAA:
+		record.AAAA = []AddressRecord{}
+	case dns.TypeCNAME:
+		record.CNAME = []CNAME{}
+	case dns.TypeNS:
+		record.NS = []NSRecord{}
+	case dns.TypeMX:
+		record.MX = []MXRecord{}
+	case dns.TypeTXT:
+		record.TXT = []TXTRecord{}
+	case dns.TypeSOA:
+		record.SOA = []SOARecord{}
+	case dns.TypeCAA:
+		record.CAA = []CAARecord{}
+	case dns.TypeSRV:
+		record.SRV = []SRVRecord{}
+	case dns.TypeHTTPS:
+		record.HTTPS = []SVCBRecord{}
+	case dns.TypeSVCB:
+		record.SVCB = []SVCBRecord{}
+	case dns.TypeDS:
+		record.DS = []DSRecord{}
+	case dns.TypeDNSKEY:
+		record.DNSKEY = []DNSKEYRecord{}
+	}
+
+	for _, rr := range rrs {
+		ttl := rr.Header().Ttl
+		switch rr := rr.(type) {
+		case *dns.A:
+			record.A = append(record.A, AddressRecord{Address: rr.A.String(), TTL: ttl})
+		case *dns.AAAA:
+			record.AAAA = append(record.AAAA, AddressRecord{Address: rr.AAAA.String(), TTL: ttl})
+		case *dns.CNAME:
+			record.CNAME = append(record.CNAME, CNAME{Name: hostName(rr.Hdr.Name), Target: hostName(rr.Target), TTL: ttl})
+		case *dns.NS:
+			record.NS = append(record.NS, NSRecord{Host: hostName(rr.Ns), TTL: ttl})
+		case *dns.MX:
+			record.MX = append(record.MX, MXRecord{Host: hostName(rr.Mx), Preference: rr.Preference, TTL: ttl})
+		case *dns.TXT:
+			record.TXT = append(record.TXT, TXTRecord{Text: strings.Join(rr.Txt, ""), TTL: ttl})
+		case *dns.SOA:
+			record.SOA = append(record.SOA, SOARecord{
+				PrimaryNS:  hostName(rr.Ns),
+				AdminEmail: mailbox(rr.Mbox),
+				Serial:     rr.Serial,
+				Refresh:    rr.Refresh,
+				Retry:      rr.Retry,
+				Expire:     rr.Expire,
+				MinTTL:     rr.Minttl,
+				TTL:        ttl,
+			})
+		case *dns.CAA:
+			record.CAA = append(record.CAA, CAARecord{Flag: rr.Flag, Tag: rr.Tag, Value: rr.Value, TTL: ttl})
+		case *dns.SRV:
+			record.SRV = append(record.SRV, SRVRecord{
+				Target:   hostName(rr.Target),
+				Port:     rr.Port,
+				Priority: rr.Priority,
+				Weight:   rr.Weight,
+				TTL:      ttl,
+			})
+		case *dns.HTTPS:
+			record.HTTPS = append(record.HTTPS, newSVCBRecord(&rr.SVCB))
+		case *dns.SVCB:
+			record.SVCB = append(record.SVCB, newSVCBRecord(rr))
+		case *dns.DS:
+			record.DS = append(record.DS, DSRecord{
+				KeyTag:     rr.KeyTag,
+				Algorithm:  dns.AlgorithmToString[rr.Algorithm],
+				DigestType: rr.DigestType,
+				Digest:     strings.ToLower(rr.Digest),
+				TTL:        ttl,
+			})
+		case *dns.DNSKEY:
+			record.DNSKEY = append(record.DNSKEY, DNSKEYRecord{
+				KeyTag:           rr.KeyTag(),
+				Algorithm:        dns.AlgorithmToString[rr.Algorithm],
+				Flags:            rr.Flags,
+				SecureEntryPoint: rr.Flags&dns.SEP != 0,
+				TTL:              ttl,
+			})
+		}
+	}
+}
+
+// addresses returns the addresses found by the A and AAAA lookups
+func (record *DNSRecord) addresses() []string {
+	var addrs []string
+	for _, a := range record.A {
+		addrs = append(addrs, a.Address)
+	}
+	for _, a := range record.AAAA {
+		addrs = append(addrs, a.Address)
+	}
+	return addrs
+}
@@ -0,0 +1,293 @@
+package dnsclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Transport protocols for plain DNS upstreams
+const (
+	ProtocolUDP = "udp"
+	ProtocolTCP = "tcp"
+)
+
+// DNS-over-HTTPS request methods
+const (
+	DoHMethodPost = http.MethodPost
+	DoHMethodGet  = http.MethodGet
+)
+
+// Default resolver settings, used for options left at their zero value
+const (
+	DefaultTimeout = 5 * time.Second
+	DefaultUDPSize = 1232
+)
+
+// DefaultServers are the upstreams used when no servers are configured
+var DefaultServers = []string{"1.1.1.1", "8.8.8.8"}
+
+// ErrNXDomain is returned for names that do not exist
+var ErrNXDomain = errors.New("no such domain")
+
+// Options configures a Resolver
+type Options struct {
+	// Servers are the upstreams to query. They are tried in order until one
+	// answers. A server is one of
+	//   - host or host:port: plain DNS over Protocol, port 53 by default
+	//   - udp://host[:port] or tcp://host[:port]: plain DNS over that protocol
+	//   - tls://host[:port]: DNS-over-TLS, port 853 by default
+	//   - https://host[:port]/path: DNS-over-HTTPS (RFC 8484)
+	// A #name fragment on tls:// and https:// servers sets the TLS server name
+	// the certificate is verified against, e.g. tls://1.1.1.1#cloudflare-dns.com.
+	Servers []string
+	// Timeout bounds each attempt against a single server
+	Timeout time.Duration
+	// Retries is how many more times the whole server list is tried after
+	// every server failed
+	Retries int
+	// Protocol is udp or tcp for servers without a scheme. Truncated UDP
+	// answers are always retried over TCP.
+	Protocol string
+	// UDPSize is the EDNS0 buffer size advertised in queries, 0 disables EDNS0
+	UDPSize uint16
+	// TLSCAFile is a PEM file of the CAs that tls:// and https:// server
+	// certificates are verified against instead of the system roots
+	TLSCAFile string
+	// DoHMethod is POST (the default) or GET for https:// servers
+	DoHMethod string
+}
+
+// Resolver looks up DNS records against a list of upstream servers
+type Resolver struct {
+	servers   []*upstream
+	timeout   time.Duration
+	retries   int
+	udpSize   uint16
+	dohMethod string
+}
+
+// upstream is a single server of a Resolver
+type upstream struct {
+	// network is udp, tcp, tcp-tls or https
+	network string
+	// addr is the host:port of plain DNS and DNS-over-TLS servers
+	addr string
+	// endpoint is the URL of DNS-over-HTTPS servers
+	endpoint *url.URL
+	// tlsConfig verifies the server certificate of tls:// and https:// servers
+	tlsConfig *tls.Config
+	// httpClient sends the requests to DNS-over-HTTPS servers
+	httpClient *http.Client
+}
+
+// String returns the server as it appears in errors
+func (u *upstream) String() string {
+	switch u.network {
+	case "tcp-tls":
+		return "tls://" + u.addr
+	case "https":
+		return u.endpoint.String()
+	}
+	return u.addr
+}
+
+// NewResolver creates a resolver from opts, filling in defaults for unset options
+func NewResolver(opts Options) (*Resolver, error) {
+	r := &Resolver{
+		timeout:   opts.Timeout,
+		retries:   opts.Retries,
+		udpSize:   opts.UDPSize,
+		dohMethod: strings.ToUpper(opts.DoHMethod),
+	}
+	if r.timeout <= 0 {
+		r.timeout = DefaultTimeout
+	}
+	if r.retries < 0 {
+		return nil, fmt.Errorf("retries must not be negative")
+	}
+	protocol := opts.Protocol
+	switch protocol {
+	case "":
+		protocol = ProtocolUDP
+	case ProtocolUDP, ProtocolTCP:
+	default:
+		return nil, fmt.Errorf("invalid protocol %q: must be %s or %s", protocol, ProtocolUDP, ProtocolTCP)
+	}
+	switch r.dohMethod {
+	case "":
+		r.dohMethod = DoHMethodPost
+	case DoHMethodPost, DoHMethodGet:
+	default:
+		return nil, fmt.Errorf("invalid DoH method %q: must be %s or %s", opts.DoHMethod, DoHMethodPost, DoHMethodGet)
+	}
+
+	var rootCAs *x509.CertPool
+	if opts.TLSCAFile != "" {
+		pem, err := os.ReadFile(opts.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DNS TLS CA: %w", err)
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in DNS TLS CA file %s", opts.TLSCAFile)
+		}
+	}
+
+	servers := opts.Servers
+	if len(servers) == 0 {
+		servers = DefaultServers
+	}
+	for _, server := range servers {
+		u, err := parseServer(server, protocol, rootCAs)
+		if err != nil {
+			return nil, err
+		}
+		r.servers = append(r.servers, u)
+	}
+	return r, nil
+}
+
+// parseServer parses a server of Options.Servers. Servers without a scheme use protocol.
+func parseServer(server, protocol string, rootCAs *x509.CertPool) (*upstream, error) {
+	if !strings.Contains(server, "://") {
+		addr, err := hostPort(server, "53")
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS server %q", server)
+		}
+		return &upstream{network: protocol, addr: addr}, nil
+	}
+
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid DNS server %q", server)
+	}
+	serverName := u.Fragment
+	if serverName == "" {
+		serverName = u.Hostname()
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		RootCAs:    rootCAs,
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+		addr, err := hostPort(u.Host, "53")
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS server %q", server)
+		}
+		return &upstream{network: u.Scheme, addr: addr}, nil
+	case "tls":
+		addr, err := hostPort(u.Host, "853")
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS server %q", server)
+		}
+		return &upstream{network: "tcp-tls", addr: addr, tlsConfig: tlsConfig}, nil
+	case "https":
+		endpoint := *u
+		endpoint.Fragment = ""
+		if endpoint.Path == "" {
+			endpoint.Path = "/dns-query"
+		}
+		return &upstream{
+			network:   "https",
+			endpoint:  &endpoint,
+			tlsConfig: tlsConfig,
+			httpClient: &http.Client{Transport: &http.Transport{
+				Proxy:             http.ProxyFromEnvironment,
+				TLSClientConfig:   tlsConfig,
+				ForceAttemptHTTP2: true,
+				IdleConnTimeout:   90 * time.Second,
+			}},
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid DNS server %q: scheme must be udp, tcp, tls or https", server)
+}
+
+// hostPort validates a host or host:port server and returns it as host:port
+func hostPort(server, defaultPort string) (string, error) {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		// No port, or a bare IPv6 address
+		host, port = strings.Trim(server, "[]"), defaultPort
+	}
+	if host == "" {
+		return "", fmt.Errorf("missing host")
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Lookup queries name for records of type qtype and returns the answer
+// section, which is also returned along with ErrNXDomain
+func (r *Resolver) Lookup(ctx context.Context, name string, qtype uint16) ([]dns.RR, error) {
+	resp, err := r.Exchange(ctx, name, qtype)
+	if resp == nil {
+		return nil, err
+	}
+	return resp.Answer, err
+}
+
+// Exchange queries name for records of type qtype, trying every server in turn
+// and the whole list again up to Retries times. An NXDOMAIN answer is returned
+// along with ErrNXDomain and not retried.
+func (r *Resolver) Exchange(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	if r.udpSize > 0 {
+		m.SetEdns0(r.udpSize, false)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= r.retries; attempt++ {
+		for _, server := range r.servers {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			resp, err := r.exchange(ctx, m, server)
+			if err != nil {
+				lastErr = fmt.Errorf("%s: %w", server, err)
+				continue
+			}
+			switch resp.Rcode {
+			case dns.RcodeSuccess:
+				return resp, nil
+			case dns.RcodeNameError:
+				return resp, ErrNXDomain
+			default:
+				// SERVFAIL, REFUSED and the like are worth asking another server about
+				lastErr = fmt.Errorf("%s: %s", server, dns.RcodeToString[resp.Rcode])
+			}
+		}
+	}
+	return nil, lastErr
+}
+
+// exchange sends m to a single server, switching to TCP when the UDP answer is truncated
+func (r *Resolver) exchange(ctx context.Context, m *dns.Msg, server *upstream) (*dns.Msg, error) {
+	if server.network == "https" {
+		return r.exchangeHTTPS(ctx, m, server)
+	}
+
+	c := &dns.Client{Net: server.network, Timeout: r.timeout, TLSConfig: server.tlsConfig}
+	resp, _, err := c.ExchangeContext(ctx, m, server.addr)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Truncated && server.network == ProtocolUDP {
+		c.Net = ProtocolTCP
+		resp, _, err = c.ExchangeContext(ctx, m, server.addr)
+	}
+	return resp, err
+}
@@ -0,0 +1,300 @@
+package dnsclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// startServer serves handler over UDP and TCP on the same local port and returns its address
+func startServer(t *testing.T, handler dns.HandlerFunc) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		pc.Close()
+		t.Fatal(err)
+	}
+
+	for _, srv := range []*dns.Server{
+		{PacketConn: pc, Handler: handler},
+		{Listener: l, Handler: handler},
+	} {
+		started := make(chan struct{})
+		srv.NotifyStartedFunc = func() { close(started) }
+		go srv.ActivateAndServe()
+		<-started
+		t.Cleanup(func() { srv.Shutdown() })
+	}
+	return pc.LocalAddr().String()
+}
+
+// zone answers A and TXT queries for any name, NXDOMAIN for missing.test and
+// SERVFAIL for MX queries. example.com has SOA, CAA, HTTPS and DNSKEY records
+// and 192.0.2.1 a reverse name. www.example.com is an alias of edge.cdn.test, and
+// dangling.example.com of missing.test. For partial.example.com only the
+// CNAME is returned, like from a server that does not follow chains.
+func zone(w dns.ResponseWriter, req *dns.Msg) {
+	w.WriteMsg(answer(req))
+}
+
+// answer builds the response of zone to req
+func answer(req *dns.Msg) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(req)
+	q := req.Question[0]
+	aliases := map[string]string{
+		"www.example.com.":      "edge.cdn.test.",
+		"dangling.example.com.": "missing.test.",
+		"partial.example.com.":  "edge.cdn.test.",
+	}
+	switch {
+	case aliases[q.Name] != "":
+		rr, _ := dns.NewRR(q.Name + " 60 IN CNAME " + aliases[q.Name])
+		m.Answer = append(m.Answer, rr)
+		if q.Name != "partial.example.com." {
+			target := answer(new(dns.Msg).SetQuestion(aliases[q.Name], q.Qtype))
+			m.Answer = append(m.Answer, target.Answer...)
+			m.Rcode = target.Rcode
+		}
+	case q.Name == "missing.test.":
+		m.Rcode = dns.RcodeNameError
+	case q.Qtype == dns.TypeMX:
+		m.Rcode = dns.RcodeServerFailure
+	case q.Qtype == dns.TypeA:
+		rr, _ := dns.NewRR(q.Name + " 300 IN A 192.0.2.1")
+		m.Answer = append(m.Answer, rr)
+	case q.Qtype == dns.TypeTXT:
+		rr, _ := dns.NewRR(q.Name + ` 300 IN TXT "v=spf1 -all"`)
+		m.Answer = append(m.Answer, rr)
+	case q.Name == "example.com.":
+		records := map[uint16]string{
+			dns.TypeSOA:    `SOA ns1.example.com. john\.doe.example.com. 2024010101 7200 3600 1209600 300`,
+			dns.TypeCAA:    `CAA 0 issue "letsencrypt.org"`,
+			dns.TypeHTTPS:  `HTTPS 1 . alpn="h2,h3" port=8443 ech="AEX+DQ==" no-default-alpn`,
+			dns.TypeDNSKEY: `DNSKEY 257 3 13 mdsswUyr3DPW132mOi8V9xESWE8jTo0dxCjjnopKl+GqJxpVXckHAeF+KkxLbxILfDLUT0rAK9iUzy1L53eKGQ==`,
+		}
+		if records[q.Qtype] != "" {
+			rr, err := dns.NewRR(q.Name + " 300 IN " + records[q.Qtype])
+			if err != nil {
+				panic(err)
+			}
+			m.Answer = append(m.Answer, rr)
+		}
+	case q.Name == "1.2.0.192.in-addr.arpa." && q.Qtype == dns.TypePTR:
+		rr, _ := dns.NewRR(q.Name + " 300 IN PTR host.example.net.")
+		m.Answer = append(m.Answer, rr)
+	}
+	return m
+}
+
+func newTestResolver(t *testing.T, opts Options) *Resolver {
+	t.Helper()
+	if opts.Timeout == 0 {
+		opts.Timeout = time.Second
+	}
+	r, err := NewResolver(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// lookupA returns the addresses in the A records of name
+func lookupA(r *Resolver, name string) ([]string, error) {
+	_, rrs, err := r.fetch(context.Background(), name, dns.TypeA)
+	var addrs []string
+	for _, rr := range rrs {
+		addrs = append(addrs, rr.(*dns.A).A.String())
+	}
+	return addrs, err
+}
+
+func TestResolverLookup(t *testing.T) {
+	addr := startServer(t, zone)
+	for _, protocol := range []string{ProtocolUDP, ProtocolTCP} {
+		t.Run(protocol, func(t *testing.T) {
+			r := newTestResolver(t, Options{Servers: []string{addr}, Protocol: protocol})
+			records, err := lookupA(r, "example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 || records[0] != "192.0.2.1" {
+				t.Errorf("got %v, want [192.0.2.1]", records)
+			}
+		})
+	}
+}
+
+func TestResolverRetriesTruncatedOverTCP(t *testing.T) {
+	addr := startServer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		if w.LocalAddr().Network() == "udp" {
+			m.Truncated = true
+		} else {
+			rr, _ := dns.NewRR(req.Question[0].Name + " 300 IN A 192.0.2.2")
+			m.Answer = append(m.Answer, rr)
+		}
+		w.WriteMsg(m)
+	})
+
+	r := newTestResolver(t, Options{Servers: []string{addr}})
+	records, err := lookupA(r, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0] != "192.0.2.2" {
+		t.Errorf("got %v, want the answer sent over TCP", records)
+	}
+}
+
+func TestResolverFailsOver(t *testing.T) {
+	// Nothing answers on a closed port
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := pc.LocalAddr().String()
+	pc.Close()
+
+	r := newTestResolver(t, Options{
+		Servers: []string{dead, startServer(t, zone)},
+		Timeout: 200 * time.Millisecond,
+	})
+	if _, err := lookupA(r, "example.com"); err != nil {
+		t.Errorf("expected the second server to answer, got %v", err)
+	}
+}
+
+func TestResolverHonorsContext(t *testing.T) {
+	addr := startServer(t, func(w dns.ResponseWriter, req *dns.Msg) {})
+	r := newTestResolver(t, Options{Servers: []string{addr}, Timeout: 5 * time.Second, Retries: 3})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := r.Lookup(ctx, "example.com", dns.TypeA); err == nil {
+		t.Fatal("expected an error from a server that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("lookup took %v, the context deadline was not honored", elapsed)
+	}
+}
+
+func TestGetAllRecords(t *testing.T) {
+	r := newTestResolver(t, Options{Servers: []string{startServer(t, zone)}})
+
+	record, err := r.GetAllRecords(context.Background(), "example.com", "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Domain != "example.com" || len(record.CNAMEChain) != 0 {
+		t.Errorf("domain = %q, chain = %v, want example.com without CNAMEs", record.Domain, record.CNAMEChain)
+	}
+	if len(record.A) != 1 || len(record.TXT) != 1 {
+		t.Errorf("A = %v, TXT = %v, want one record each", record.A, record.TXT)
+	}
+	if record.AAAA == nil || len(record.AAAA) != 0 {
+		t.Errorf("AAAA = %#v, want an empty list", record.AAAA)
+	}
+	if record.MX != nil || !strings.Contains(record.Errors["MX"], "SERVFAIL") {
+		t.Errorf("MX = %v, errors = %v, want the SERVFAIL reported", record.MX, record.Errors)
+	}
+	if len(record.Errors) != 1 {
+		t.Errorf("errors = %v, want only MX", record.Errors)
+	}
+
+	if _, err := r.GetAllRecords(context.Background(), "missing.test", "missing.test"); !errors.Is(err, ErrNXDomain) {
+		t.Errorf("got %v, want ErrNXDomain", err)
+	}
+}
+
+func TestGetAllRecordsFollowsCNAMEs(t *testing.T) {
+	r := newTestResolver(t, Options{Servers: []string{startServer(t, zone)}})
+	edge := []CNAME{{Name: "www.example.com", Target: "edge.cdn.test", TTL: 60}}
+
+	for _, tt := range []struct {
+		name    string
+		chain   []CNAME
+		a       []AddressRecord
+		wantErr bool
+	}{
+		{name: "www.example.com", chain: edge, a: []AddressRecord{{Address: "192.0.2.1", TTL: 300}}},
+		{name: "partial.example.com", chain: []CNAME{{Name: "partial.example.com", Target: "edge.cdn.test", TTL: 60}}, a: []AddressRecord{{Address: "192.0.2.1", TTL: 300}}},
+		{name: "dangling.example.com", chain: []CNAME{{Name: "dangling.example.com", Target: "missing.test", TTL: 60}}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := r.GetAllRecords(context.Background(), tt.name, "example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(record.CNAMEChain, tt.chain) {
+				t.Errorf("chain = %+v, want %+v", record.CNAMEChain, tt.chain)
+			}
+			if !reflect.DeepEqual(record.A, tt.a) {
+				t.Errorf("A = %v, want %v", record.A, tt.a)
+			}
+			if _, failed := record.Errors["A"]; failed != tt.wantErr {
+				t.Errorf("errors = %v, want an A error: %v", record.Errors, tt.wantErr)
+			}
+			// NS, MX and TXT are looked up at the registrable domain
+			if record.Domain != "example.com" || len(record.TXT) != 1 {
+				t.Errorf("domain = %q, TXT = %v, want the TXT record of example.com", record.Domain, record.TXT)
+			}
+		})
+	}
+}
+
+func TestGetAllRecordsExtendedTypes(t *testing.T) {
+	r := newTestResolver(t, Options{Servers: []string{startServer(t, zone)}})
+
+	record, err := r.GetAllRecords(context.Background(), "example.com", "example.com",
+		dns.TypeSOA, dns.TypeCAA, dns.TypeHTTPS, dns.TypeDNSKEY, dns.TypePTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(record.Errors) != 0 {
+		t.Errorf("errors = %v, want none", record.Errors)
+	}
+	if record.MX != nil || record.TXT != nil {
+		t.Errorf("MX = %v, TXT = %v, want types that were not requested left out", record.MX, record.TXT)
+	}
+
+	soa := []SOARecord{{
+		PrimaryNS: "ns1.example.com", AdminEmail: "john.doe@example.com", Serial: 2024010101,
+		Refresh: 7200, Retry: 3600, Expire: 1209600, MinTTL: 300, TTL: 300,
+	}}
+	if !reflect.DeepEqual(record.SOA, soa) {
+		t.Errorf("SOA = %+v, want %+v", record.SOA, soa)
+	}
+	if caa := []CAARecord{{Tag: "issue", Value: "letsencrypt.org", TTL: 300}}; !reflect.DeepEqual(record.CAA, caa) {
+		t.Errorf("CAA = %+v, want %+v", record.CAA, caa)
+	}
+	https := []SVCBRecord{{
+		Priority: 1, Target: ".", ALPN: []string{"h2", "h3"}, Port: 8443, ECH: true,
+		Params: map[string]string{"no-default-alpn": ""}, TTL: 300,
+	}}
+	if !reflect.DeepEqual(record.HTTPS, https) {
+		t.Errorf("HTTPS = %+v, want %+v", record.HTTPS, https)
+	}
+	if len(record.DNSKEY) != 1 || !record.DNSKEY[0].SecureEntryPoint || record.DNSKEY[0].Algorithm != "ECDSAP256SHA256" {
+		t.Errorf("DNSKEY = %+v, want a key signing key with ECDSAP256SHA256", record.DNSKEY)
+	}
+	// PTR looks up the addresses as well
+	if ptr := []PTRRecord{{Address: "192.0.2.1", Host: "host.example.net", TTL: 300}}; !reflect.DeepEqual(record.PTR, ptr) {
+		t.Errorf("PTR = %+v, want %+v", record.PTR, ptr)
+	}
+	if len(record.A) != 1 {
+		t.Errorf("A = %+v, want the address the PTR record was looked up for", record.A)
+	}
+}
@@ -3,19 +3,19 @@ module github.com/ajinux/pi-hole-mcp-server
 go 1.25.3
 
 require (
+	github.com/google/jsonschema-go v0.3.0
 	github.com/joho/godotenv v1.5.1
 	github.com/likexian/whois v1.15.6
 	github.com/likexian/whois-parser v1.24.20
 	github.com/miekg/dns v1.1.68
 	github.com/modelcontextprotocol/go-sdk v1.1.0
+	golang.org/x/net v0.47.0
 )
 
 require (
-	github.com/google/jsonschema-go v0.3.0 // indirect
 	github.com/likexian/gokit v0.25.15 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	golang.org/x/mod v0.29.0 // indirect
-	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/oauth2 v0.30.0 // indirect
 	golang.org/x/sync v0.18.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
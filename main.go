@@ -2,14 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ajinux/pi-hole-mcp-server/config"
+	"github.com/ajinux/pi-hole-mcp-server/dnsclient"
 	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
 	"github.com/ajinux/pi-hole-mcp-server/tools"
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -20,6 +27,9 @@ var (
 	date    = "unknown"
 )
 
+// shutdownTimeout bounds how long in-flight tool calls are given to finish on shutdown
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	log.Printf(
 		"starting pihole-mcp server version=%s commit=%s built=%s",
@@ -29,45 +39,175 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	// Create Pi-hole client
-	ctx := context.Background()
-	piholeClient, err := client.NewClient(ctx, cfg.PiHoleURL, cfg.PiHolePassword)
+	// Stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Create a Pi-hole client per instance
+	var instances []tools.Instance
+	for _, inst := range cfg.Instances {
+		password, totpSecret := inst.Credentials()
+		piholeClient, err := client.NewClient(ctx, inst.URL, client.Credentials{
+			Password:   password,
+			TOTPSecret: totpSecret,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create Pi-hole client for instance %q: %v", inst.Name, err)
+		}
+		instances = append(instances, tools.Instance{Name: inst.Name, Client: piholeClient})
+	}
+
+	// Create logger for MCP connections. Everything is logged to stderr, stdout
+	// carries the MCP stream when serving over stdio.
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+
+	resolver, err := dnsclient.NewResolver(dnsclient.Options{
+		Servers:   cfg.DNS.Servers,
+		Timeout:   cfg.DNS.Timeout,
+		Retries:   cfg.DNS.Retries,
+		Protocol:  cfg.DNS.Protocol,
+		UDPSize:   cfg.DNS.UDPSize,
+		TLSCAFile: cfg.DNS.TLSCAFile,
+		DoHMethod: cfg.DNS.DoHMethod,
+	})
 	if err != nil {
-		log.Fatalf("Failed to create Pi-hole client: %v", err)
+		log.Fatalf("Failed to create DNS resolver: %v", err)
+	}
+
+	// Use the embedded Public Suffix List unless a newer one is configured
+	var suffixes *dnsclient.SuffixList
+	if cfg.PublicSuffixList != "" {
+		if suffixes, err = dnsclient.LoadSuffixList(cfg.PublicSuffixList); err != nil {
+			log.Fatalf("Failed to load public suffix list: %v", err)
+		}
 	}
 
+	toolRegistry := tools.NewRegistry(instances, resolver, suffixes, cfg.Mode == config.ModeReadOnly, logger)
+	log.Printf("Running in %s mode", cfg.Mode)
+
 	// Create MCP server
 	mserv := mcp.NewServer(&mcp.Implementation{
 		Name:    "Pi hole mcp server",
 		Title:   "Pi hole MCP Server",
 		Version: "1.0",
-	}, nil)
-
-	// Create logger for MCP connections
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	}, &mcp.ServerOptions{
+		Logger:             logger,
+		SubscribeHandler:   toolRegistry.Subscribe,
+		UnsubscribeHandler: toolRegistry.Unsubscribe,
+	})
 
-	// Register all tools
-	toolRegistry := tools.NewRegistry(piholeClient, logger)
+	// Register all tools, resources and prompts
 	toolRegistry.RegisterAll(mserv)
+	go toolRegistry.WatchResources(ctx, mserv, cfg.ResourcePollInterval)
+
+	for _, inst := range cfg.Instances {
+		log.Printf("Connected to Pi-hole %q at: %s", inst.Name, inst.URL)
+	}
+
+	// The stdio session outlives ctx so in-flight tool calls can still reply during shutdown
+	runCtx, stopRun := context.WithCancel(context.Background())
+	defer stopRun()
+
+	var srv *http.Server
+	serveErr := make(chan error, 1)
+	if cfg.Transport == config.TransportStdio {
+		if len(cfg.AuthTokens) > 0 || cfg.TLSCertFile != "" {
+			log.Printf("Auth tokens and TLS only apply to the HTTP transports, ignoring them for stdio")
+		}
+		log.Printf("Serving Pi-hole MCP server over stdio")
+		go func() {
+			serveErr <- mserv.Run(runCtx, &mcp.StdioTransport{})
+		}()
+	} else {
+		handler := newHTTPHandler(cfg.Transport, mserv, logger)
+		if len(cfg.AuthTokens) > 0 {
+			handler = auth.RequireBearerToken(newTokenVerifier(cfg.AuthTokens), nil)(handler)
+		} else if cfg.TLSClientCAFile == "" {
+			log.Printf("WARNING: no auth tokens configured, anyone who can reach port %s can use the server", cfg.Port)
+		}
+
+		tlsConfig, err := newTLSConfig(cfg)
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
+		}
+		srv = &http.Server{
+			Addr:      ":" + cfg.Port,
+			Handler:   handler,
+			TLSConfig: tlsConfig,
+		}
 
-	// Create StreamableHTTP handler that returns our MCP server
-	handler := mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server {
+		scheme := "http"
+		if cfg.TLSCertFile != "" {
+			scheme = "https"
+		}
+		log.Printf("Starting Pi-hole MCP server on %s://localhost:%s (%s transport)", scheme, cfg.Port, cfg.Transport)
+		go func() {
+			if cfg.TLSCertFile != "" {
+				serveErr <- srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+			} else {
+				serveErr <- srv.ListenAndServe()
+			}
+		}()
+	}
+
+	exitCode := 0
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, io.EOF) {
+			log.Printf("Server stopped: %v", err)
+			exitCode = 1
+		}
+	case <-ctx.Done():
+		log.Printf("Shutting down, waiting up to %s for in-flight tool calls", shutdownTimeout)
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections, let in-flight tool calls finish, then drop
+	// long-lived streams that would otherwise keep Shutdown waiting
+	if srv != nil {
+		go srv.Shutdown(shutdownCtx)
+	}
+	if err := toolRegistry.Drain(shutdownCtx); err != nil {
+		log.Printf("Timed out waiting for in-flight tool calls: %v", err)
+	}
+	if srv != nil {
+		srv.Close()
+	}
+	stopRun()
+
+	for _, inst := range instances {
+		if err := inst.Client.Close(shutdownCtx); err != nil {
+			log.Printf("Failed to log out of Pi-hole %q: %v", inst.Name, err)
+		}
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// newHTTPHandler returns the HTTP handler serving mserv over the Streamable HTTP or SSE transport
+func newHTTPHandler(transport string, mserv *mcp.Server, logger *slog.Logger) http.Handler {
+	getServer := func(r *http.Request) *mcp.Server {
+		tokenName, certName := requestIdentity(r)
 		logger.Info("New MCP client connection",
 			"remote_addr", r.RemoteAddr,
 			"user_agent", r.UserAgent(),
 			"session_id", r.Header.Get("Mcp-Session-Id"),
+			"token_name", tokenName,
+			"client_cert", certName,
 		)
 		return mserv
-	}, &mcp.StreamableHTTPOptions{
-		Logger: logger,
-	})
-
-	log.Printf("Starting Pi-hole MCP server on http://localhost:%s", cfg.Port)
-	log.Printf("Connected to Pi-hole at: %s", cfg.PiHoleURL)
+	}
 
-	if err := http.ListenAndServe(":"+cfg.Port, handler); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	if transport == config.TransportSSE {
+		return mcp.NewSSEHandler(getServer, nil)
 	}
+	return mcp.NewStreamableHTTPHandler(getServer, &mcp.StreamableHTTPOptions{
+		Logger: logger,
+	})
 }
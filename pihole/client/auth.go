@@ -2,16 +2,34 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"time"
 )
 
 const (
 	loginEndpoint = "auth"
+
+	// sessionRefreshMargin is how long before expiry a session is proactively renewed
+	sessionRefreshMargin = 30 * time.Second
 )
 
+// Credentials holds the secrets used to log in to Pi-hole
+type Credentials struct {
+	// Password is either the web interface password or an application password.
+	// Application passwords are not subject to two-factor authentication.
+	Password string
+	// TOTPSecret is the base32 encoded two-factor secret, required when 2FA is
+	// enabled on Pi-hole and Password is not an application password
+	TOTPSecret string
+}
+
 type authRequest struct {
 	Password string `json:"password"`
+	Totp     *int   `json:"totp,omitempty"`
 }
 
 type authResponse struct {
@@ -26,15 +44,170 @@ type authResponse struct {
 	Took float64 `json:"took"`
 }
 
-func (c *Client) obtainSessionID(ctx context.Context) (string, error) {
-	res := &authResponse{}
-	err := c.postJSON(ctx, loginEndpoint, authRequest{Password: c.password}, res)
+// obtainSessionID logs in to Pi-hole. The caller must hold loginMu.
+func (c *Client) obtainSessionID(ctx context.Context) (*authResponse, error) {
+	req := authRequest{Password: c.credentials.Password}
+	if c.credentials.TOTPSecret != "" {
+		code, err := c.nextTOTPCode(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Totp = &code
+	}
+
+	resp, err := c.send(ctx, http.MethodPost, loginEndpoint, "", req)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	res := &authResponse{}
+	decodeErr := json.Unmarshal(bodyBytes, res)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Pi-hole answers rejected logins with a 401 that still carries a session object
+		if decodeErr != nil || res.Session.Message == "" {
+			return nil, newHTTPError(resp.StatusCode, resp.Status, string(bodyBytes), http.MethodPost, c.baseURL+loginEndpoint)
+		}
+	} else if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", decodeErr)
 	}
 	if !res.Session.Valid {
-		return "", fmt.Errorf("authentication failed: %s", res.Session.Message)
+		if res.Session.Totp && c.credentials.TOTPSecret == "" {
+			return nil, fmt.Errorf("authentication failed: Pi-hole requires a two-factor code, configure a TOTP secret or use an application password")
+		}
+		if res.Session.Totp {
+			return nil, fmt.Errorf("authentication failed: two-factor code rejected (check the TOTP secret and the system clock): %s", res.Session.Message)
+		}
+		return nil, fmt.Errorf("authentication failed: %s", res.Session.Message)
 	}
 	log.Printf("obtained a new pi-hole session token successfully")
-	return res.Session.Sid, nil
+	return res, nil
+}
+
+// Close logs out the current session so it does not keep occupying one of
+// Pi-hole's limited API seats. The client must not be used afterwards.
+func (c *Client) Close(ctx context.Context) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	c.mu.Lock()
+	sid := c.sessionID
+	c.sessionID = ""
+	c.expiresAt = time.Time{}
+	c.mu.Unlock()
+
+	if sid == "" {
+		return nil
+	}
+
+	resp, err := c.send(ctx, http.MethodDelete, loginEndpoint, sid, nil)
+	if err != nil {
+		return fmt.Errorf("failed to log out: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// A 401 means the session had already expired, which is just as good
+	if resp.StatusCode != http.StatusUnauthorized && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return newHTTPError(resp.StatusCode, resp.Status, string(bodyBytes), http.MethodDelete, c.baseURL+loginEndpoint)
+	}
+	log.Printf("logged out of pi-hole session")
+	return nil
+}
+
+// nextTOTPCode generates the two-factor code for the current time step. Pi-hole
+// refuses to accept the same code twice, so if the previous login already used
+// this time step it waits for the next one. The caller must hold loginMu.
+func (c *Client) nextTOTPCode(ctx context.Context) (int, error) {
+	now := time.Now()
+	if counter := totpCounter(now); counter <= c.lastTOTPCounter {
+		next := time.Unix(int64(c.lastTOTPCounter+1)*int64(totpStep/time.Second), 0)
+		timer := time.NewTimer(time.Until(next))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+		now = next
+	}
+
+	code, err := totpCode(c.credentials.TOTPSecret, now)
+	if err != nil {
+		return 0, err
+	}
+	c.lastTOTPCounter = totpCounter(now)
+	return code, nil
+}
+
+// session returns the current session ID, logging in again when the session
+// is missing or about to expire
+func (c *Client) session(ctx context.Context) (string, error) {
+	c.mu.RLock()
+	sid, fresh := c.sessionID, c.sessionFresh()
+	c.mu.RUnlock()
+	if fresh {
+		return sid, nil
+	}
+	return c.login(ctx, sid)
+}
+
+// login obtains a new session ID. Concurrent callers that observed the same stale
+// session share a single login: whoever gets loginMu first authenticates and the
+// others pick up the session it stored.
+func (c *Client) login(ctx context.Context, staleSID string) (string, error) {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	c.mu.RLock()
+	sid, fresh := c.sessionID, c.sessionFresh()
+	c.mu.RUnlock()
+	if sid != staleSID && fresh {
+		return sid, nil
+	}
+
+	res, err := c.obtainSessionID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionID = res.Session.Sid
+	// Pi-hole reports a non-positive validity when no password is configured,
+	// in which case the session never expires
+	c.validity = time.Duration(res.Session.Validity) * time.Second
+	c.expiresAt = time.Now().Add(c.validity)
+	return c.sessionID, nil
+}
+
+// touchSession extends the expiry of the session after a successful request,
+// since Pi-hole renews a session's validity every time it is used
+func (c *Client) touchSession(sid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sessionID == sid && c.validity > 0 {
+		c.expiresAt = time.Now().Add(c.validity)
+	}
+}
+
+// sessionFresh reports whether the current session can be used without renewal.
+// The caller must hold mu.
+func (c *Client) sessionFresh() bool {
+	if c.expiresAt.IsZero() {
+		return false
+	}
+	if c.validity <= 0 {
+		return true
+	}
+	margin := sessionRefreshMargin
+	if c.validity < 2*margin {
+		margin = c.validity / 2
+	}
+	return time.Until(c.expiresAt) > margin
 }
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePihole is a minimal stand-in for the FTL API that issues sessions on
+// /api/auth and requires a valid session on every other endpoint
+type fakePihole struct {
+	// mux can be used by tests to register additional endpoints
+	mux *http.ServeMux
+
+	mu       sync.Mutex
+	sessions map[string]bool
+	logins   atomic.Int32
+	rejected atomic.Int32
+	validity int
+
+	password    string
+	appPassword string
+	// totpSecret enables two-factor authentication for password logins
+	totpSecret string
+}
+
+func newFakePihole(t *testing.T) (*fakePihole, *httptest.Server) {
+	t.Helper()
+	f := &fakePihole{sessions: map[string]bool{}, validity: 1800, password: "secret"}
+	mux := http.NewServeMux()
+	f.mux = mux
+	mux.HandleFunc("POST /api/auth", func(w http.ResponseWriter, r *http.Request) {
+		var req authRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		res := authResponse{}
+		switch {
+		case req.Password == f.appPassword && f.appPassword != "":
+		case req.Password != f.password:
+			res.Session.Message = "password incorrect"
+		case f.totpSecret != "" && req.Totp == nil:
+			res.Session.Totp = true
+			res.Session.Message = "password correct but TOTP missing"
+		case f.totpSecret != "":
+			// Like FTL, accept codes from the neighbouring time steps to allow for clock drift
+			valid := false
+			for _, skew := range []time.Duration{-totpStep, 0, totpStep} {
+				if want, _ := totpCode(f.totpSecret, time.Now().Add(skew)); *req.Totp == want {
+					valid = true
+				}
+			}
+			if !valid {
+				res.Session.Totp = true
+				res.Session.Message = "password correct but TOTP invalid"
+			}
+		}
+		if res.Session.Message != "" {
+			res.Session.Validity = -1
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(res)
+			return
+		}
+
+		n := f.logins.Add(1)
+		sid := fmt.Sprintf("sid-%d", n)
+		f.mu.Lock()
+		f.sessions[sid] = true
+		f.mu.Unlock()
+		res.Session.Valid = true
+		res.Session.Totp = f.totpSecret != ""
+		res.Session.Sid = sid
+		res.Session.Validity = f.validity
+		res.Session.Message = "password correct"
+		json.NewEncoder(w).Encode(res)
+	})
+	mux.HandleFunc("DELETE /api/auth", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		sid := r.Header.Get(authHeader)
+		if !f.sessions[sid] {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		delete(f.sessions, sid)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /api/stats/top_clients", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		ok := f.sessions[r.Header.Get(authHeader)]
+		f.mu.Unlock()
+		if !ok {
+			f.rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"key":"unauthorized","message":"Unauthorized"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"clients":[{"ip":"10.0.0.2","name":"laptop","count":3}],"total_queries":3}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+// expireAll invalidates every session server-side, as FTL does after a timeout
+func (f *fakePihole) expireAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions = map[string]bool{}
+}
+
+func TestClientReauthenticatesOn401(t *testing.T) {
+	f, srv := newFakePihole(t)
+	ctx := context.Background()
+
+	c, err := NewClient(ctx, srv.URL+"/api", Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	f.expireAll()
+
+	stats, err := c.GetTopActiveClientsByUsage(ctx, 10)
+	if err != nil {
+		t.Fatalf("GetTopActiveClientsByUsage() error = %v", err)
+	}
+	if len(stats.Clients) != 1 {
+		t.Errorf("got %d clients, want 1", len(stats.Clients))
+	}
+	if got := f.logins.Load(); got != 2 {
+		t.Errorf("logins = %d, want 2", got)
+	}
+	if got := f.rejected.Load(); got != 1 {
+		t.Errorf("rejected requests = %d, want 1", got)
+	}
+}
+
+func TestClientSingleFlightLogin(t *testing.T) {
+	f, srv := newFakePihole(t)
+	ctx := context.Background()
+
+	c, err := NewClient(ctx, srv.URL+"/api", Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	f.expireAll()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetTopActiveClientsByUsage(ctx, 10); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("GetTopActiveClientsByUsage() error = %v", err)
+	}
+	if got := f.logins.Load(); got != 2 {
+		t.Errorf("logins = %d, want 2 (initial login plus a single re-login)", got)
+	}
+}
+
+func TestClientRefreshesBeforeExpiry(t *testing.T) {
+	f, srv := newFakePihole(t)
+	ctx := context.Background()
+
+	c, err := NewClient(ctx, srv.URL+"/api", Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// Pretend the session is about to lapse
+	c.mu.Lock()
+	c.expiresAt = time.Now().Add(sessionRefreshMargin / 2)
+	c.mu.Unlock()
+
+	if _, err := c.GetTopActiveClientsByUsage(ctx, 10); err != nil {
+		t.Fatalf("GetTopActiveClientsByUsage() error = %v", err)
+	}
+	if got := f.logins.Load(); got != 2 {
+		t.Errorf("logins = %d, want 2", got)
+	}
+	if got := f.rejected.Load(); got != 0 {
+		t.Errorf("rejected requests = %d, want 0", got)
+	}
+}
+
+func TestClientWrongPassword(t *testing.T) {
+	_, srv := newFakePihole(t)
+
+	_, err := NewClient(context.Background(), srv.URL+"/api", Credentials{Password: "wrong"})
+	if err == nil || !strings.Contains(err.Error(), "password incorrect") {
+		t.Errorf("NewClient() error = %v, want password incorrect", err)
+	}
+}
+
+func TestClientTOTP(t *testing.T) {
+	const totpSecret = "JBSWY3DPEHPK3PXP"
+
+	tests := []struct {
+		name        string
+		credentials Credentials
+		wantErr     string
+	}{
+		{
+			name:        "missing totp secret",
+			credentials: Credentials{Password: "secret"},
+			wantErr:     "requires a two-factor code",
+		},
+		{
+			name:        "wrong totp secret",
+			credentials: Credentials{Password: "secret", TOTPSecret: "GEZDGNBVGY3TQOJQ"},
+			wantErr:     "two-factor code rejected",
+		},
+		{
+			name:        "valid totp secret",
+			credentials: Credentials{Password: "secret", TOTPSecret: totpSecret},
+		},
+		{
+			name:        "application password",
+			credentials: Credentials{Password: "app-password"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakePihole(t)
+			f.appPassword = "app-password"
+			f.totpSecret = totpSecret
+
+			c, err := NewClient(context.Background(), srv.URL+"/api", tt.credentials)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewClient() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if _, err := c.GetTopActiveClientsByUsage(context.Background(), 10); err != nil {
+				t.Errorf("GetTopActiveClientsByUsage() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	f, srv := newFakePihole(t)
+	ctx := context.Background()
+
+	c, err := NewClient(ctx, srv.URL+"/api", Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if err := c.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f.mu.Lock()
+	remaining := len(f.sessions)
+	f.mu.Unlock()
+	if remaining != 0 {
+		t.Errorf("%d sessions left open after Close(), want 0", remaining)
+	}
+
+	// Closing twice is a no-op
+	if err := c.Close(ctx); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+}
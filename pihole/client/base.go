@@ -9,23 +9,35 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
 type endpoint string
 
 const (
 	authHeader = "X-FTL-SID"
+	userAgent  = "pihole-mcp-server"
 )
 
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
 
-	password  string
+	credentials Credentials
+
+	// mu guards the session state below, which is shared by concurrent tool calls
+	mu        sync.RWMutex
 	sessionID string
+	validity  time.Duration
+	expiresAt time.Time
+
+	// loginMu ensures only one login is in flight at a time and guards lastTOTPCounter
+	loginMu         sync.Mutex
+	lastTOTPCounter uint64
 }
 
-func NewClient(ctx context.Context, baseURL, password string) (*Client, error) {
+func NewClient(ctx context.Context, baseURL string, credentials Credentials) (*Client, error) {
 	if baseURL == "" {
 		return nil, errors.New("baseURL is empty")
 	}
@@ -36,40 +48,18 @@ func NewClient(ctx context.Context, baseURL, password string) (*Client, error) {
 	}
 
 	c := &Client{
-		baseURL:    baseURL,
-		httpClient: http.DefaultClient,
-		password:   password,
+		baseURL:     baseURL,
+		httpClient:  http.DefaultClient,
+		credentials: credentials,
 	}
-	sid, err := c.obtainSessionID(ctx)
-	if err != nil {
+	if _, err := c.login(ctx, ""); err != nil {
 		return nil, fmt.Errorf("could not obtain session ID: %w", err)
 	}
-	c.sessionID = sid
 	return c, nil
 }
 
-// get performs a GET request to the specified endpoint
-func (c *Client) get(ctx context.Context, endpoint string) (*http.Response, error) {
-	url := c.baseURL + endpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GET request: %w", err)
-	}
-
-	if c.sessionID != "" {
-		req.Header.Set(authHeader, c.sessionID)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute GET request: %w", err)
-	}
-
-	return resp, nil
-}
-
-// post performs a POST request to the specified endpoint with the given payload
-func (c *Client) post(ctx context.Context, endpoint string, payload any) (*http.Response, error) {
+// send performs a single HTTP request to the specified endpoint using the given session ID
+func (c *Client) send(ctx context.Context, method, endpoint, sessionID string, payload any) (*http.Response, error) {
 	var body io.Reader
 	if payload != nil {
 		jsonData, err := json.Marshal(payload)
@@ -80,47 +70,87 @@ func (c *Client) post(ctx context.Context, endpoint string, payload any) (*http.
 	}
 
 	url := c.baseURL + endpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create POST request: %w", err)
+		return nil, fmt.Errorf("failed to create %s request: %w", method, err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.sessionID != "" {
-		req.Header.Set(authHeader, c.sessionID)
+	req.Header.Set("User-Agent", userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if sessionID != "" {
+		req.Header.Set(authHeader, sessionID)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute POST request: %w", err)
+		return nil, fmt.Errorf("failed to execute %s request: %w", method, err)
 	}
 
 	return resp, nil
 }
 
-// getJSON performs a GET request and decodes the JSON response into the target
-func (c *Client) getJSON(ctx context.Context, endpoint string, target any) error {
-	resp, err := c.get(ctx, endpoint)
+// do performs an authenticated request. If Pi-hole rejects the session with a 401
+// the client logs in again and retries the request once.
+func (c *Client) do(ctx context.Context, method, endpoint string, payload any) (*http.Response, error) {
+	sid, err := c.session(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return newHTTPError(resp.StatusCode, resp.Status, string(bodyBytes), http.MethodGet, c.baseURL+endpoint)
+	resp, err := c.send(ctx, method, endpoint, sid, payload)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		sid, err = c.login(ctx, sid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-authenticate: %w", err)
+		}
+		resp, err = c.send(ctx, method, endpoint, sid, payload)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		c.touchSession(sid)
+	}
+
+	return resp, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into the target
+func (c *Client) getJSON(ctx context.Context, endpoint string, target any) error {
+	return c.doJSON(ctx, http.MethodGet, endpoint, nil, target)
 }
 
 // postJSON performs a POST request and decodes the JSON response into the target
 func (c *Client) postJSON(ctx context.Context, endpoint string, payload any, target any) error {
-	resp, err := c.post(ctx, endpoint, payload)
+	return c.doJSON(ctx, http.MethodPost, endpoint, payload, target)
+}
+
+// putJSON performs a PUT request and decodes the JSON response into the target
+func (c *Client) putJSON(ctx context.Context, endpoint string, payload any, target any) error {
+	return c.doJSON(ctx, http.MethodPut, endpoint, payload, target)
+}
+
+// patchJSON performs a PATCH request and decodes the JSON response into the target
+func (c *Client) patchJSON(ctx context.Context, endpoint string, payload any, target any) error {
+	return c.doJSON(ctx, http.MethodPatch, endpoint, payload, target)
+}
+
+// deleteJSON performs a DELETE request, expecting no response body
+func (c *Client) deleteJSON(ctx context.Context, endpoint string) error {
+	return c.doJSON(ctx, http.MethodDelete, endpoint, nil, nil)
+}
+
+// doJSON performs an authenticated request and decodes the JSON response into the target
+func (c *Client) doJSON(ctx context.Context, method, endpoint string, payload any, target any) error {
+	resp, err := c.do(ctx, method, endpoint, payload)
 	if err != nil {
 		return err
 	}
@@ -128,7 +158,7 @@ func (c *Client) postJSON(ctx context.Context, endpoint string, payload any, tar
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return newHTTPError(resp.StatusCode, resp.Status, string(bodyBytes), http.MethodPost, c.baseURL+endpoint)
+		return newHTTPError(resp.StatusCode, resp.Status, string(bodyBytes), method, c.baseURL+endpoint)
 	}
 
 	if target != nil {
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	BlockingEnabled  = "enabled"
+	BlockingDisabled = "disabled"
+)
+
+// BlockingStatus is Pi-hole's global blocking state. Timer holds the seconds
+// remaining until the state flips back, if a timer is running.
+type BlockingStatus struct {
+	Blocking string   `json:"blocking"`
+	Timer    *float64 `json:"timer"`
+	Took     float64  `json:"took"`
+}
+
+type setBlockingRequest struct {
+	Blocking bool     `json:"blocking"`
+	Timer    *float64 `json:"timer"`
+}
+
+func (c *Client) GetBlockingStatus(ctx context.Context) (*BlockingStatus, error) {
+	var res BlockingStatus
+	err := c.getJSON(ctx, "dns/blocking", &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocking status: %w", err)
+	}
+	return &res, nil
+}
+
+// SetBlocking enables or disables blocking. A positive timer makes Pi-hole
+// revert to the opposite state once it elapses.
+func (c *Client) SetBlocking(ctx context.Context, enabled bool, timer time.Duration) (*BlockingStatus, error) {
+	req := setBlockingRequest{Blocking: enabled}
+	if timer > 0 {
+		seconds := timer.Seconds()
+		req.Timer = &seconds
+	}
+
+	var res BlockingStatus
+	err := c.postJSON(ctx, "dns/blocking", req, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set blocking status: %w", err)
+	}
+	return &res, nil
+}
@@ -24,7 +24,6 @@ func (c *Client) GetTopDomainsQueried(ctx context.Context) ([]*Domain, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error getting top domains queried: %w", err)
 	}
-	fmt.Printf("top allowed domains: %v\n", topAllowedDomains.Domains)
 	var topBlockedDomains TopDomainStats
 	err = c.getJSON(ctx, "stats/top_domains?blocked=true", &topBlockedDomains)
 	if err != nil {
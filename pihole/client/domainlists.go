@@ -0,0 +1,280 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	DomainTypeAllow = "allow"
+	DomainTypeDeny  = "deny"
+
+	DomainKindExact = "exact"
+	DomainKindRegex = "regex"
+)
+
+// DomainRule is an entry of Pi-hole's allow or deny list
+type DomainRule struct {
+	Domain       string   `json:"domain"`
+	Unicode      string   `json:"unicode"`
+	Type         string   `json:"type"`
+	Kind         string   `json:"kind"`
+	Comment      *string  `json:"comment"`
+	Groups       []int    `json:"groups"`
+	Enabled      bool     `json:"enabled"`
+	Id           int      `json:"id"`
+	DateAdded    UnixTime `json:"date_added"`
+	DateModified UnixTime `json:"date_modified"`
+}
+
+// DomainRuleInput holds the fields of a domain rule that can be set when adding or updating it
+type DomainRuleInput struct {
+	Comment *string `json:"comment,omitempty"`
+	Groups  []int   `json:"groups,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+}
+
+// ProcessedItems reports which items of a batch request Pi-hole accepted
+type ProcessedItems struct {
+	Success []struct {
+		Item string `json:"item"`
+	} `json:"success"`
+	Errors []struct {
+		Item  string `json:"item"`
+		Error string `json:"error"`
+	} `json:"errors"`
+}
+
+type DomainRules struct {
+	Domains   []DomainRule    `json:"domains"`
+	Processed *ProcessedItems `json:"processed"`
+	Took      float64         `json:"took"`
+}
+
+type addDomainRulesRequest struct {
+	Domain []string `json:"domain"`
+	DomainRuleInput
+}
+
+type updateDomainRuleRequest struct {
+	Type string `json:"type"`
+	Kind string `json:"kind"`
+	DomainRuleInput
+}
+
+// GetDomainRules lists allow/deny list entries. Empty type or kind match all.
+func (c *Client) GetDomainRules(ctx context.Context, listType, kind string) ([]DomainRule, error) {
+	if err := validateDomainList(listType, kind, true); err != nil {
+		return nil, err
+	}
+
+	path := "domains"
+	if listType != "" {
+		path += "/" + listType
+		if kind != "" {
+			path += "/" + kind
+		}
+	}
+
+	var res DomainRules
+	if err := c.getJSON(ctx, path, &res); err != nil {
+		return nil, fmt.Errorf("failed to get domain rules: %w", err)
+	}
+
+	// The API cannot filter by kind alone
+	if listType == "" && kind != "" {
+		filtered := res.Domains[:0]
+		for _, rule := range res.Domains {
+			if rule.Kind == kind {
+				filtered = append(filtered, rule)
+			}
+		}
+		res.Domains = filtered
+	}
+	return res.Domains, nil
+}
+
+// AddDomainRules adds one or more domains or regular expressions to the allow or
+// deny list. Regular expressions are validated locally first.
+func (c *Client) AddDomainRules(ctx context.Context, listType, kind string, domains []string, input DomainRuleInput) (*DomainRules, error) {
+	if err := validateDomainList(listType, kind, false); err != nil {
+		return nil, err
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("no domains given")
+	}
+	if kind == DomainKindRegex {
+		for _, pattern := range domains {
+			if err := ValidateRegex(pattern); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var res DomainRules
+	err := c.postJSON(ctx, fmt.Sprintf("domains/%s/%s", listType, kind), addDomainRulesRequest{
+		Domain:          domains,
+		DomainRuleInput: input,
+	}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add domain rules: %w", err)
+	}
+	return &res, nil
+}
+
+// UpdateDomainRule changes the comment, groups or enabled state of an existing rule
+func (c *Client) UpdateDomainRule(ctx context.Context, listType, kind, domain string, input DomainRuleInput) (*DomainRules, error) {
+	if err := validateDomainList(listType, kind, false); err != nil {
+		return nil, err
+	}
+
+	var res DomainRules
+	err := c.putJSON(ctx, domainRulePath(listType, kind, domain), updateDomainRuleRequest{
+		Type:            listType,
+		Kind:            kind,
+		DomainRuleInput: input,
+	}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update domain rule: %w", err)
+	}
+	return &res, nil
+}
+
+// DeleteDomainRule removes a domain or regular expression from the allow or deny list
+func (c *Client) DeleteDomainRule(ctx context.Context, listType, kind, domain string) error {
+	if err := validateDomainList(listType, kind, false); err != nil {
+		return err
+	}
+
+	if err := c.deleteJSON(ctx, domainRulePath(listType, kind, domain)); err != nil {
+		return fmt.Errorf("failed to delete domain rule: %w", err)
+	}
+	return nil
+}
+
+func domainRulePath(listType, kind, domain string) string {
+	return fmt.Sprintf("domains/%s/%s/%s", listType, kind, url.PathEscape(domain))
+}
+
+// validateDomainList checks the list type and kind, optionally allowing them to be empty
+func validateDomainList(listType, kind string, allowEmpty bool) error {
+	switch listType {
+	case DomainTypeAllow, DomainTypeDeny:
+	case "":
+		if !allowEmpty {
+			return fmt.Errorf("domain list type is required")
+		}
+	default:
+		return fmt.Errorf("invalid domain list type %q, must be %q or %q", listType, DomainTypeAllow, DomainTypeDeny)
+	}
+	switch kind {
+	case DomainKindExact, DomainKindRegex:
+	case "":
+		if !allowEmpty {
+			return fmt.Errorf("domain list kind is required")
+		}
+	default:
+		return fmt.Errorf("invalid domain list kind %q, must be %q or %q", kind, DomainKindExact, DomainKindRegex)
+	}
+	return nil
+}
+
+// regexOptionPattern matches the Pi-hole specific options that may follow a regex, e.g. ;querytype=AAAA
+var regexOptionPattern = regexp.MustCompile(`^(querytype=!?[A-Za-z0-9,]+|reply=[A-Za-z0-9.:]+|invert)$`)
+
+// ValidateRegex checks a regex rule for mistakes before it is sent to Pi-hole.
+// FTL compiles rules as POSIX extended regular expressions with TRE, which
+// also supports backreferences and approximate matching, so only the structure
+// is checked: parentheses and brackets must be balanced and ranges in order.
+// Pi-hole's own extensions (;querytype=, ;reply=, ;invert) are accepted.
+func ValidateRegex(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("empty regex")
+	}
+
+	expr, options, _ := strings.Cut(pattern, ";")
+	if options != "" {
+		for _, option := range strings.Split(options, ";") {
+			if !regexOptionPattern.MatchString(option) {
+				return fmt.Errorf("invalid regex %q: unknown option %q", pattern, option)
+			}
+		}
+	}
+
+	if err := checkERE(expr); err != nil {
+		return fmt.Errorf("invalid regex %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// checkERE reports unbalanced parentheses, unterminated brackets and
+// reversed ranges in a POSIX extended regular expression
+func checkERE(expr string) error {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			i++
+			if i == len(expr) {
+				return fmt.Errorf("trailing backslash")
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return fmt.Errorf("unmatched )")
+			}
+			depth--
+		case '[':
+			end, err := checkBracket(expr, i)
+			if err != nil {
+				return err
+			}
+			i = end
+		}
+	}
+	if depth > 0 {
+		return fmt.Errorf("missing )")
+	}
+	return nil
+}
+
+// checkBracket checks the bracket expression starting at expr[start] and
+// returns the index of its closing bracket
+func checkBracket(expr string, start int) (int, error) {
+	i := start + 1
+	if i < len(expr) && expr[i] == '^' {
+		i++
+	}
+	// A leading ] is a literal
+	if i < len(expr) && expr[i] == ']' {
+		i++
+	}
+	prev := -1
+	for ; i < len(expr); i++ {
+		switch {
+		case expr[i] == ']':
+			return i, nil
+		case expr[i] == '[' && i+1 < len(expr) && strings.IndexByte(":.=", expr[i+1]) >= 0:
+			// Character classes like [:alnum:], collating elements and equivalence classes
+			end := strings.Index(expr[i+2:], string(expr[i+1])+"]")
+			if end < 0 {
+				return 0, fmt.Errorf("unterminated %s in bracket expression", expr[i:i+2])
+			}
+			i += end + 3
+			prev = -1
+		case expr[i] == '-' && prev >= 0 && i+1 < len(expr) && expr[i+1] != ']':
+			if int(expr[i+1]) < prev {
+				return 0, fmt.Errorf("invalid range %s", expr[i-1:i+2])
+			}
+			i++
+			prev = -1
+		default:
+			prev = int(expr[i])
+		}
+	}
+	return 0, fmt.Errorf("missing ]")
+}
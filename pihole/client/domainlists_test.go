@@ -0,0 +1,37 @@
+package client
+
+import "testing"
+
+func TestValidateRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{`(\.|^)doubleclick\.net$`, false},
+		{`^ad[0-9]*\.example\.com$`, false},
+		{`^[[:alnum:]]+\.tracker\.io$`, false},
+		{`^abc\.example\.com$;querytype=AAAA`, false},
+		{`^abc\.example\.com$;querytype=!A,AAAA;invert`, false},
+		{`^abc\.example\.com$;reply=NXDOMAIN`, false},
+		// POSIX ERE as compiled by TRE, which RE2 rejects
+		{`^(.)\1`, false},
+		{`^doubleclick{~1}\.net$`, false},
+		{`^a{,3}\.example\.com$`, false},
+		{`[]a-]\.example\.com`, false},
+		{`[^[:digit:].]+\.lan`, false},
+		{`(\.|^doubleclick\.net$`, true},
+		{`^abc$;querytpe=A`, true},
+		{`[z-a]`, true},
+		{`^ads)\.example\.com$`, true},
+		{`^[a-z\.example\.com$`, true},
+		{`[[:alpha]`, true},
+		{`\`, true},
+		{``, true},
+	}
+	for _, tt := range tests {
+		err := ValidateRegex(tt.pattern)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateRegex(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GravitySummary is the outcome of a gravity update, parsed from its output
+type GravitySummary struct {
+	// Completed is set when gravity reported it finished
+	Completed bool
+	// GravityDomains and UniqueDomains are the number of domains on gravity after the update
+	GravityDomains int
+	UniqueDomains  int
+	// FailedLists holds the addresses of lists that could not be downloaded
+	FailedLists []string
+}
+
+var (
+	ansiEscapePattern    = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+	gravityTargetPattern = regexp.MustCompile(`\[i\] Target: (\S+)`)
+	gravityDomainPattern = regexp.MustCompile(`Number of gravity domains: (\d+) \((\d+) unique domains\)`)
+)
+
+// UpdateGravity triggers a gravity update, which downloads all subscribed lists
+// and rebuilds the blocking database. FTL streams the output of the run, each
+// line is passed to onLine as it arrives. The returned summary is parsed from
+// the complete output.
+func (c *Client) UpdateGravity(ctx context.Context, onLine func(line string)) (*GravitySummary, error) {
+	resp, err := c.do(ctx, http.MethodPost, "action/gravity", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update gravity: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to update gravity: %w", newHTTPError(resp.StatusCode, resp.Status, string(bodyBytes), http.MethodPost, c.baseURL+"action/gravity"))
+	}
+
+	summary := &GravitySummary{}
+	parser := gravityParser{summary: summary}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Split(scanGravityLines)
+	for scanner.Scan() {
+		line := cleanGravityLine(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parser.parse(line)
+		if onLine != nil {
+			onLine(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return summary, fmt.Errorf("failed to read gravity output: %w", err)
+	}
+	return summary, nil
+}
+
+// gravityParser accumulates a summary line by line
+type gravityParser struct {
+	summary *GravitySummary
+	target  string
+}
+
+func (p *gravityParser) parse(line string) {
+	if m := gravityTargetPattern.FindStringSubmatch(line); m != nil {
+		p.target = m[1]
+		return
+	}
+	if m := gravityDomainPattern.FindStringSubmatch(line); m != nil {
+		p.summary.GravityDomains, _ = strconv.Atoi(m[1])
+		p.summary.UniqueDomains, _ = strconv.Atoi(m[2])
+		// All lists have been processed by now
+		p.target = ""
+		return
+	}
+	if strings.HasPrefix(line, "[✗]") && p.target != "" {
+		if n := len(p.summary.FailedLists); n == 0 || p.summary.FailedLists[n-1] != p.target {
+			p.summary.FailedLists = append(p.summary.FailedLists, p.target)
+		}
+		return
+	}
+	if strings.HasPrefix(line, "[✓] Done") {
+		p.summary.Completed = true
+	}
+}
+
+// cleanGravityLine strips terminal escape sequences and surrounding whitespace
+func cleanGravityLine(line string) string {
+	return strings.TrimSpace(ansiEscapePattern.ReplaceAllString(line, ""))
+}
+
+// scanGravityLines is a bufio.SplitFunc that splits on both \n and \r, since
+// gravity rewrites progress lines in place with carriage returns
+func scanGravityLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const gravityOutput = "  [i] Neutrino emissions detected...\n\x1b[K  [✓] Pulling blocklist source list into range\n" +
+	"  [i] Target: https://lists.example.com/hosts\r  [✓] Status: Retrieval successful\n" +
+	"  [✓] Parsed 123 exact domains and 0 ABP-style domains (blocking, ignored 0 non-domain entries)\n" +
+	"  [i] Target: https://broken.example.org/list.txt\n  [✗] Status: Not found\n" +
+	"  [✗] List download failed: no cached list available\n" +
+	"  [i] Number of gravity domains: 158954 (143214 unique domains)\n" +
+	"  [✓] Done.\n"
+
+func TestClientUpdateGravity(t *testing.T) {
+	f, srv := newFakePihole(t)
+	f.mux.HandleFunc("POST /api/action/gravity", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, gravityOutput)
+	})
+	ctx := context.Background()
+
+	c, err := NewClient(ctx, srv.URL+"/api", Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	var lines []string
+	summary, err := c.UpdateGravity(ctx, func(line string) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatalf("UpdateGravity() error = %v", err)
+	}
+
+	want := &GravitySummary{
+		Completed:      true,
+		GravityDomains: 158954,
+		UniqueDomains:  143214,
+		FailedLists:    []string{"https://broken.example.org/list.txt"},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("UpdateGravity() summary = %+v, want %+v", summary, want)
+	}
+	if len(lines) != 10 {
+		t.Errorf("got %d progress lines, want 10: %q", len(lines), lines)
+	}
+	if lines[1] != "[✓] Pulling blocklist source list into range" {
+		t.Errorf("escape sequences not stripped: %q", lines[1])
+	}
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultGroupID is the ID of Pi-hole's built-in Default group, which applies
+// to every client that is not explicitly assigned to other groups
+const DefaultGroupID = 0
+
+type Group struct {
+	Name         string   `json:"name"`
+	Comment      *string  `json:"comment"`
+	Enabled      bool     `json:"enabled"`
+	Id           int      `json:"id"`
+	DateAdded    UnixTime `json:"date_added"`
+	DateModified UnixTime `json:"date_modified"`
+}
+
+type Groups struct {
+	Groups    []Group         `json:"groups"`
+	Processed *ProcessedItems `json:"processed"`
+	Took      float64         `json:"took"`
+}
+
+// GroupInput holds the fields of a group that can be set when adding or updating it
+type GroupInput struct {
+	Comment *string `json:"comment,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+}
+
+type addGroupRequest struct {
+	Name string `json:"name"`
+	GroupInput
+}
+
+// ManagedClient is a client with an explicit group assignment. Client is an
+// IP address, subnet, MAC address, hostname or interface (prefixed with ':').
+type ManagedClient struct {
+	Client       string   `json:"client"`
+	Name         *string  `json:"name"`
+	Comment      *string  `json:"comment"`
+	Groups       []int    `json:"groups"`
+	Id           int      `json:"id"`
+	DateAdded    UnixTime `json:"date_added"`
+	DateModified UnixTime `json:"date_modified"`
+}
+
+type ManagedClients struct {
+	Clients   []ManagedClient `json:"clients"`
+	Processed *ProcessedItems `json:"processed"`
+	Took      float64         `json:"took"`
+}
+
+// ManagedClientInput holds the fields of a managed client that can be set when adding or updating it
+type ManagedClientInput struct {
+	Comment *string `json:"comment,omitempty"`
+	Groups  []int   `json:"groups"`
+}
+
+type addManagedClientRequest struct {
+	Client string `json:"client"`
+	ManagedClientInput
+}
+
+func (c *Client) GetGroups(ctx context.Context) ([]Group, error) {
+	var res Groups
+	err := c.getJSON(ctx, "groups", &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	return res.Groups, nil
+}
+
+func (c *Client) AddGroup(ctx context.Context, name string, input GroupInput) (*Groups, error) {
+	var res Groups
+	err := c.postJSON(ctx, "groups", addGroupRequest{Name: name, GroupInput: input}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add group: %w", err)
+	}
+	return &res, nil
+}
+
+// UpdateGroup changes a group's comment or enabled state, or renames it when newName differs from name
+func (c *Client) UpdateGroup(ctx context.Context, name, newName string, input GroupInput) (*Groups, error) {
+	var res Groups
+	err := c.putJSON(ctx, "groups/"+url.PathEscape(name), addGroupRequest{Name: newName, GroupInput: input}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update group: %w", err)
+	}
+	return &res, nil
+}
+
+func (c *Client) DeleteGroup(ctx context.Context, name string) error {
+	if err := c.deleteJSON(ctx, "groups/"+url.PathEscape(name)); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetManagedClients(ctx context.Context) ([]ManagedClient, error) {
+	var res ManagedClients
+	err := c.getJSON(ctx, "clients", &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get managed clients: %w", err)
+	}
+	return res.Clients, nil
+}
+
+func (c *Client) AddManagedClient(ctx context.Context, clientID string, input ManagedClientInput) (*ManagedClients, error) {
+	var res ManagedClients
+	err := c.postJSON(ctx, "clients", addManagedClientRequest{Client: clientID, ManagedClientInput: input}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add client: %w", err)
+	}
+	return &res, nil
+}
+
+func (c *Client) UpdateManagedClient(ctx context.Context, clientID string, input ManagedClientInput) (*ManagedClients, error) {
+	var res ManagedClients
+	err := c.putJSON(ctx, "clients/"+url.PathEscape(clientID), input, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update client: %w", err)
+	}
+	return &res, nil
+}
+
+func (c *Client) DeleteManagedClient(ctx context.Context, clientID string) error {
+	if err := c.deleteJSON(ctx, "clients/"+url.PathEscape(clientID)); err != nil {
+		return fmt.Errorf("failed to delete client: %w", err)
+	}
+	return nil
+}
+
+var (
+	hostnamePattern  = regexp.MustCompile(`^([a-zA-Z0-9_]([a-zA-Z0-9_-]{0,61}[a-zA-Z0-9_])?\.)*[a-zA-Z0-9_]([a-zA-Z0-9_-]{0,61}[a-zA-Z0-9_])?\.?$`)
+	interfacePattern = regexp.MustCompile(`^:[a-zA-Z0-9_.-]+$`)
+)
+
+// NormalizeClientID validates a client identifier (IP address, subnet in CIDR
+// notation, MAC address, hostname or ':interface') and returns it in the form
+// Pi-hole stores it
+func NormalizeClientID(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if ip := net.ParseIP(id); ip != nil {
+		return ip.String(), nil
+	}
+	if _, subnet, err := net.ParseCIDR(id); err == nil {
+		return subnet.String(), nil
+	}
+	if mac, err := net.ParseMAC(id); err == nil {
+		return mac.String(), nil
+	}
+	if interfacePattern.MatchString(id) {
+		return id, nil
+	}
+	if len(id) <= 253 && hostnamePattern.MatchString(id) {
+		return strings.ToLower(strings.TrimSuffix(id, ".")), nil
+	}
+	return "", fmt.Errorf("invalid client %q: expected an IP address, subnet (CIDR), MAC address, hostname or :interface", id)
+}
+
+// GroupMembership resolves which groups apply to a client
+type GroupMembership struct {
+	Groups  map[int]Group
+	Clients []ManagedClient
+}
+
+// GetGroupMembership fetches the groups and managed clients needed to resolve group membership
+func (c *Client) GetGroupMembership(ctx context.Context) (*GroupMembership, error) {
+	groups, err := c.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := c.GetManagedClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &GroupMembership{Groups: make(map[int]Group, len(groups)), Clients: clients}
+	for _, g := range groups {
+		m.Groups[g.Id] = g
+	}
+	return m, nil
+}
+
+// GroupsFor returns the groups applying to a client, matching managed clients
+// in the same order as FTL: exact IP address, most specific subnet, MAC
+// address, then hostname. Unmatched clients belong to the Default group.
+func (m *GroupMembership) GroupsFor(ip string, macs []string, hostname string) []Group {
+	if entry := m.match(ip, macs, hostname); entry != nil {
+		return m.lookup(entry.Groups)
+	}
+	return m.lookup([]int{DefaultGroupID})
+}
+
+func (m *GroupMembership) match(ip string, macs []string, hostname string) *ManagedClient {
+	parsedIP := net.ParseIP(ip)
+
+	if parsedIP != nil {
+		for i, entry := range m.Clients {
+			if entryIP := net.ParseIP(entry.Client); entryIP != nil && entryIP.Equal(parsedIP) {
+				return &m.Clients[i]
+			}
+		}
+
+		var best *ManagedClient
+		bestBits := -1
+		for i, entry := range m.Clients {
+			_, subnet, err := net.ParseCIDR(entry.Client)
+			if err != nil || !subnet.Contains(parsedIP) {
+				continue
+			}
+			if bits, _ := subnet.Mask.Size(); bits > bestBits {
+				best, bestBits = &m.Clients[i], bits
+			}
+		}
+		if best != nil {
+			return best
+		}
+	}
+
+	for _, mac := range macs {
+		hw, err := net.ParseMAC(strings.TrimSpace(mac))
+		if err != nil {
+			continue
+		}
+		for i, entry := range m.Clients {
+			if entryMAC, err := net.ParseMAC(entry.Client); err == nil && entryMAC.String() == hw.String() {
+				return &m.Clients[i]
+			}
+		}
+	}
+
+	if hostname != "" {
+		for i, entry := range m.Clients {
+			if strings.EqualFold(entry.Client, hostname) {
+				return &m.Clients[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *GroupMembership) lookup(ids []int) []Group {
+	groups := make([]Group, 0, len(ids))
+	for _, id := range ids {
+		if g, ok := m.Groups[id]; ok {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
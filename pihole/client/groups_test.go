@@ -0,0 +1,78 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeClientID(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{"192.168.1.20", "192.168.1.20", false},
+		{"fd00::0001", "fd00::1", false},
+		{"192.168.1.0/24", "192.168.1.0/24", false},
+		{"192.168.1.7/24", "192.168.1.0/24", false},
+		{"AA:BB:CC:DD:EE:FF", "aa:bb:cc:dd:ee:ff", false},
+		{"Kids-Tablet.lan.", "kids-tablet.lan", false},
+		{":eth0", ":eth0", false},
+		{"not a client", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeClientID(tt.id)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeClientID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeClientID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestGroupMembershipGroupsFor(t *testing.T) {
+	m := &GroupMembership{
+		Groups: map[int]Group{
+			0: {Id: 0, Name: "Default"},
+			1: {Id: 1, Name: "kids"},
+			2: {Id: 2, Name: "iot"},
+			3: {Id: 3, Name: "guests"},
+		},
+		Clients: []ManagedClient{
+			{Client: "192.168.1.0/24", Groups: []int{0}},
+			{Client: "192.168.1.128/25", Groups: []int{3}},
+			{Client: "192.168.1.50", Groups: []int{1}},
+			{Client: "aa:bb:cc:dd:ee:ff", Groups: []int{2}},
+			{Client: "tv.lan", Groups: []int{0, 2}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		ip       string
+		macs     []string
+		hostname string
+		want     []string
+	}{
+		{"exact ip wins over subnet", "192.168.1.50", nil, "", []string{"kids"}},
+		{"most specific subnet", "192.168.1.200", nil, "", []string{"guests"}},
+		{"subnet", "192.168.1.10", nil, "", []string{"Default"}},
+		{"mac", "10.0.0.5", []string{"AA:BB:CC:DD:EE:FF"}, "", []string{"iot"}},
+		{"hostname", "10.0.0.6", nil, "TV.lan", []string{"Default", "iot"}},
+		{"unmanaged client", "10.0.0.7", nil, "", []string{"Default"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, g := range m.GroupsFor(tt.ip, tt.macs, tt.hostname) {
+				got = append(got, g.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GroupsFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HistoryWindow is how far back Pi-hole keeps query history in memory. Older
+// history has to be read from the long-term database.
+const HistoryWindow = 24 * time.Hour
+
+// HistoryPoint holds the query counts of one 10 minute interval
+type HistoryPoint struct {
+	Timestamp float64 `json:"timestamp"`
+	Total     int     `json:"total"`
+	Cached    int     `json:"cached"`
+	Blocked   int     `json:"blocked"`
+	Forwarded int     `json:"forwarded"`
+}
+
+// Time returns the start of the interval
+func (p HistoryPoint) Time() time.Time {
+	return time.Unix(int64(p.Timestamp), 0)
+}
+
+type History struct {
+	History []HistoryPoint `json:"history"`
+	Took    float64        `json:"took"`
+}
+
+// ClientHistoryPoint holds the number of queries per client IP in one 10 minute interval
+type ClientHistoryPoint struct {
+	Timestamp float64        `json:"timestamp"`
+	Data      map[string]int `json:"data"`
+}
+
+// Time returns the start of the interval
+func (p ClientHistoryPoint) Time() time.Time {
+	return time.Unix(int64(p.Timestamp), 0)
+}
+
+type HistoryClient struct {
+	Name  *string `json:"name"`
+	Total int     `json:"total"`
+}
+
+// ClientHistory is the query history split by client. Clients is keyed by client IP.
+type ClientHistory struct {
+	Clients map[string]HistoryClient `json:"clients"`
+	History []ClientHistoryPoint     `json:"history"`
+	Took    float64                  `json:"took"`
+}
+
+// GetHistory returns the query history of the last 24 hours
+func (c *Client) GetHistory(ctx context.Context) ([]HistoryPoint, error) {
+	var res History
+	err := c.getJSON(ctx, "history", &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query history: %w", err)
+	}
+	return res.History, nil
+}
+
+// GetHistoryRange returns the query history between from and until from the
+// long-term database
+func (c *Client) GetHistoryRange(ctx context.Context, from, until time.Time) ([]HistoryPoint, error) {
+	var res History
+	err := c.getJSON(ctx, fmt.Sprintf("history/database?from=%d&until=%d", from.Unix(), until.Unix()), &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query history: %w", err)
+	}
+	return res.History, nil
+}
+
+// GetClientHistory returns the per-client query history of the last 24 hours
+// for every client
+func (c *Client) GetClientHistory(ctx context.Context) (*ClientHistory, error) {
+	var res ClientHistory
+	// N=0 returns all clients instead of the 20 most active ones
+	err := c.getJSON(ctx, "history/clients?N=0", &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client query history: %w", err)
+	}
+	return &res, nil
+}
+
+// GetClientHistoryRange returns the per-client query history between from and
+// until from the long-term database
+func (c *Client) GetClientHistoryRange(ctx context.Context, from, until time.Time) (*ClientHistory, error) {
+	var res ClientHistory
+	err := c.getJSON(ctx, fmt.Sprintf("history/database/clients?from=%d&until=%d", from.Unix(), until.Unix()), &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client query history: %w", err)
+	}
+	return &res, nil
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+const (
+	ListTypeBlock = "block"
+	ListTypeAllow = "allow"
+)
+
+// List status codes reported by Pi-hole after the last gravity run
+const (
+	ListStatusUnknown   = 0
+	ListStatusUpdated   = 1
+	ListStatusUnchanged = 2
+	ListStatusCached    = 3
+	ListStatusFailed    = 4
+)
+
+// List is a subscribed adlist (or allowlist) that gravity downloads domains from
+type List struct {
+	Address        string   `json:"address"`
+	Comment        *string  `json:"comment"`
+	Groups         []int    `json:"groups"`
+	Enabled        bool     `json:"enabled"`
+	Id             int      `json:"id"`
+	Type           string   `json:"type"`
+	Number         int      `json:"number"`
+	InvalidDomains int      `json:"invalid_domains"`
+	AbpEntries     int      `json:"abp_entries"`
+	Status         int      `json:"status"`
+	DateAdded      UnixTime `json:"date_added"`
+	DateModified   UnixTime `json:"date_modified"`
+	DateUpdated    UnixTime `json:"date_updated"`
+}
+
+type Lists struct {
+	Lists     []List          `json:"lists"`
+	Processed *ProcessedItems `json:"processed"`
+	Took      float64         `json:"took"`
+}
+
+// ListInput holds the fields of a list that can be set when adding or updating it
+type ListInput struct {
+	Comment *string `json:"comment,omitempty"`
+	Groups  []int   `json:"groups,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+}
+
+type addListsRequest struct {
+	Address []string `json:"address"`
+	ListInput
+}
+
+type updateListRequest struct {
+	Type string `json:"type"`
+	ListInput
+}
+
+// GetLists returns the subscribed lists of the given type, or all lists if listType is empty
+func (c *Client) GetLists(ctx context.Context, listType string) ([]List, error) {
+	if err := validateListType(listType, true); err != nil {
+		return nil, err
+	}
+
+	path := "lists"
+	if listType != "" {
+		path += "?type=" + listType
+	}
+
+	var res Lists
+	if err := c.getJSON(ctx, path, &res); err != nil {
+		return nil, fmt.Errorf("failed to get lists: %w", err)
+	}
+	return res.Lists, nil
+}
+
+// AddLists subscribes to one or more lists. The domains are only loaded on the next gravity update.
+func (c *Client) AddLists(ctx context.Context, listType string, addresses []string, input ListInput) (*Lists, error) {
+	if err := validateListType(listType, false); err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no list addresses given")
+	}
+
+	var res Lists
+	err := c.postJSON(ctx, "lists?type="+listType, addListsRequest{Address: addresses, ListInput: input}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add lists: %w", err)
+	}
+	return &res, nil
+}
+
+// UpdateList changes the comment, groups or enabled state of a subscribed list
+func (c *Client) UpdateList(ctx context.Context, listType, address string, input ListInput) (*Lists, error) {
+	if err := validateListType(listType, false); err != nil {
+		return nil, err
+	}
+
+	var res Lists
+	err := c.putJSON(ctx, listPath(listType, address), updateListRequest{Type: listType, ListInput: input}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update list: %w", err)
+	}
+	return &res, nil
+}
+
+// DeleteList unsubscribes from a list
+func (c *Client) DeleteList(ctx context.Context, listType, address string) error {
+	if err := validateListType(listType, false); err != nil {
+		return err
+	}
+
+	if err := c.deleteJSON(ctx, listPath(listType, address)); err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+	return nil
+}
+
+func listPath(listType, address string) string {
+	return fmt.Sprintf("lists/%s?type=%s", url.PathEscape(address), listType)
+}
+
+func validateListType(listType string, allowEmpty bool) error {
+	switch listType {
+	case ListTypeBlock, ListTypeAllow:
+		return nil
+	case "":
+		if allowEmpty {
+			return nil
+		}
+		return fmt.Errorf("list type is required")
+	}
+	return fmt.Errorf("invalid list type %q, must be %q or %q", listType, ListTypeBlock, ListTypeAllow)
+}
@@ -0,0 +1,334 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LocalDNSRecord is an entry of Pi-hole's dns.hosts setting, mapping one or
+// more hostnames to an IP address
+type LocalDNSRecord struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+
+	entry string
+}
+
+// LocalCNAME is an entry of Pi-hole's dns.cnameRecords setting. TTL is zero
+// when the entry does not set one.
+type LocalCNAME struct {
+	Domains []string `json:"domains"`
+	Target  string   `json:"target"`
+	TTL     int      `json:"ttl,omitempty"`
+
+	entry string
+}
+
+// LocalDNS holds Pi-hole's local DNS records and CNAMEs. Entries that could not
+// be parsed, like hand edits, are listed as they are in UnparsedHosts and
+// UnparsedCNAMEs. They are never written back, since changes are made one
+// entry at a time.
+type LocalDNS struct {
+	Records []LocalDNSRecord
+	CNAMEs  []LocalCNAME
+
+	UnparsedHosts  []string
+	UnparsedCNAMEs []string
+}
+
+type dnsConfig struct {
+	Hosts        *[]string `json:"hosts,omitempty"`
+	CnameRecords *[]string `json:"cnameRecords,omitempty"`
+}
+
+type dnsConfigBody struct {
+	Config struct {
+		DNS dnsConfig `json:"dns"`
+	} `json:"config"`
+}
+
+func (c *Client) GetLocalDNS(ctx context.Context) (*LocalDNS, error) {
+	var res dnsConfigBody
+	err := c.getJSON(ctx, "config/dns", &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local DNS config: %w", err)
+	}
+
+	local := &LocalDNS{}
+	if res.Config.DNS.Hosts != nil {
+		for _, entry := range *res.Config.DNS.Hosts {
+			if record, ok := parseLocalDNSRecord(entry); ok {
+				local.Records = append(local.Records, record)
+			} else {
+				local.UnparsedHosts = append(local.UnparsedHosts, entry)
+			}
+		}
+	}
+	if res.Config.DNS.CnameRecords != nil {
+		for _, entry := range *res.Config.DNS.CnameRecords {
+			if cname, ok := parseLocalCNAME(entry); ok {
+				local.CNAMEs = append(local.CNAMEs, cname)
+			} else {
+				local.UnparsedCNAMEs = append(local.UnparsedCNAMEs, entry)
+			}
+		}
+	}
+	return local, nil
+}
+
+// LocalDNSEdit changes one entry of Pi-hole's dns.hosts or dns.cnameRecords
+// setting: Old is removed and replaced by New, unless New is empty
+type LocalDNSEdit struct {
+	Setting string
+	Old     string
+	New     string
+}
+
+// AddLocalDNSRecord adds record to Pi-hole's dns.hosts setting
+func (c *Client) AddLocalDNSRecord(ctx context.Context, record LocalDNSRecord) error {
+	err := c.putJSON(ctx, localDNSEndpoint("hosts", record.String()), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add local DNS record: %w", err)
+	}
+	return nil
+}
+
+// AddLocalCNAME adds cname to Pi-hole's dns.cnameRecords setting
+func (c *Client) AddLocalCNAME(ctx context.Context, cname LocalCNAME) error {
+	err := c.putJSON(ctx, localDNSEndpoint("cnameRecords", cname.String()), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add local CNAME record: %w", err)
+	}
+	return nil
+}
+
+// EditLocalDNS applies edit, adding the new entry before removing the old one
+// so that a failure never leaves the hostname without either
+func (c *Client) EditLocalDNS(ctx context.Context, edit LocalDNSEdit) error {
+	if edit.New != "" {
+		err := c.putJSON(ctx, localDNSEndpoint(edit.Setting, edit.New), nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to update local DNS entry %q: %w", edit.Old, err)
+		}
+	}
+	if err := c.deleteJSON(ctx, localDNSEndpoint(edit.Setting, edit.Old)); err != nil {
+		return fmt.Errorf("failed to remove local DNS entry %q: %w", edit.Old, err)
+	}
+	return nil
+}
+
+func localDNSEndpoint(setting, entry string) string {
+	return fmt.Sprintf("config/dns/%s/%s", setting, url.PathEscape(entry))
+}
+
+// String formats the record the way Pi-hole stores it: "IP hostname [hostname...]"
+func (r LocalDNSRecord) String() string {
+	return strings.Join(append([]string{r.IP}, r.Hostnames...), " ")
+}
+
+// String formats the CNAME the way Pi-hole stores it: "domain[,domain...],target[,ttl]"
+func (c LocalCNAME) String() string {
+	parts := append(slices.Clone(c.Domains), c.Target)
+	if c.TTL > 0 {
+		parts = append(parts, strconv.Itoa(c.TTL))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseLocalDNSRecord parses a dns.hosts entry. Entries that would not be
+// written back the same, like those with comments, are not parsed.
+func parseLocalDNSRecord(entry string) (LocalDNSRecord, bool) {
+	fields := strings.Fields(entry)
+	if len(fields) < 2 || net.ParseIP(fields[0]) == nil || strings.Contains(entry, "#") {
+		return LocalDNSRecord{}, false
+	}
+	return LocalDNSRecord{IP: fields[0], Hostnames: fields[1:], entry: entry}, true
+}
+
+// parseLocalCNAME parses a dns.cnameRecords entry
+func parseLocalCNAME(entry string) (LocalCNAME, bool) {
+	parts := strings.Split(strings.TrimSpace(entry), ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	var cname LocalCNAME
+	if len(parts) > 2 {
+		if ttl, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+			cname.TTL = ttl
+			parts = parts[:len(parts)-1]
+		}
+	}
+	if len(parts) < 2 || slices.Contains(parts, "") {
+		return LocalCNAME{}, false
+	}
+	cname.Domains = parts[:len(parts)-1]
+	cname.Target = parts[len(parts)-1]
+	cname.entry = entry
+	return cname, true
+}
+
+// NormalizeHostname validates a hostname and returns it lowercased without a trailing dot
+func NormalizeHostname(hostname string) (string, error) {
+	hostname = strings.TrimSpace(hostname)
+	if hostname == "" || len(hostname) > 253 || !hostnamePattern.MatchString(hostname) {
+		return "", fmt.Errorf("invalid hostname %q", hostname)
+	}
+	return strings.ToLower(strings.TrimSuffix(hostname, ".")), nil
+}
+
+// HostIPs returns the IP addresses hostname resolves to through local DNS records
+func (l *LocalDNS) HostIPs(hostname string) []string {
+	var ips []string
+	for _, record := range l.Records {
+		if containsHostname(record.Hostnames, hostname) {
+			ips = append(ips, record.IP)
+		}
+	}
+	return ips
+}
+
+// CNAMETarget returns the target of the local CNAME for domain, if there is one
+func (l *LocalDNS) CNAMETarget(domain string) (string, bool) {
+	for _, cname := range l.CNAMEs {
+		if containsHostname(cname.Domains, domain) {
+			return cname.Target, true
+		}
+	}
+	return "", false
+}
+
+// CheckRecord reports whether mapping hostname to ip would conflict with the
+// existing entries: the same mapping already exists, the hostname is a CNAME,
+// or it already resolves to another address of the same family.
+func (l *LocalDNS) CheckRecord(ip, hostname string) error {
+	if target, ok := l.CNAMETarget(hostname); ok {
+		return fmt.Errorf("%s is already a CNAME for %s", hostname, target)
+	}
+	isV4 := net.ParseIP(ip).To4() != nil
+	for _, existing := range l.HostIPs(hostname) {
+		if net.ParseIP(existing).Equal(net.ParseIP(ip)) {
+			return fmt.Errorf("%s already resolves to %s", hostname, ip)
+		}
+		if parsed := net.ParseIP(existing); parsed != nil && (parsed.To4() != nil) == isV4 {
+			return fmt.Errorf("%s already resolves to %s", hostname, existing)
+		}
+	}
+	return nil
+}
+
+// CheckCNAME reports whether pointing domain at target would conflict with
+// the existing entries: the domain already has a record or CNAME, or the new
+// CNAME would create a loop.
+func (l *LocalDNS) CheckCNAME(domain, target string) error {
+	if domain == target {
+		return fmt.Errorf("CNAME %s cannot point to itself", domain)
+	}
+	if existing, ok := l.CNAMETarget(domain); ok {
+		return fmt.Errorf("%s is already a CNAME for %s", domain, existing)
+	}
+	if ips := l.HostIPs(domain); len(ips) > 0 {
+		return fmt.Errorf("%s already has a local DNS record (%s)", domain, strings.Join(ips, ", "))
+	}
+
+	// Follow the target's chain to make sure it does not lead back to domain
+	seen := map[string]bool{domain: true}
+	for next := target; ; {
+		if seen[next] {
+			return fmt.Errorf("CNAME %s -> %s would create a loop", domain, target)
+		}
+		seen[next] = true
+		var ok bool
+		if next, ok = l.CNAMETarget(next); !ok {
+			return nil
+		}
+	}
+}
+
+// RemoveHostname removes hostname from the local DNS records and CNAMEs. If ip
+// is not empty only the records for that address are touched. It returns the
+// edits that make the same change on Pi-hole.
+func (l *LocalDNS) RemoveHostname(hostname, ip string) []LocalDNSEdit {
+	var edits []LocalDNSEdit
+	var keptRecords []LocalDNSRecord
+	for _, record := range l.Records {
+		if containsHostname(record.Hostnames, hostname) && (ip == "" || net.ParseIP(record.IP).Equal(net.ParseIP(ip))) {
+			edit := LocalDNSEdit{Setting: "hosts", Old: record.stored()}
+			record.Hostnames = slices.DeleteFunc(slices.Clone(record.Hostnames), func(h string) bool {
+				return strings.EqualFold(strings.TrimSuffix(h, "."), hostname)
+			})
+			record.entry = ""
+			if len(record.Hostnames) > 0 && !l.hasHostsEntry(record.String()) {
+				edit.New = record.String()
+				keptRecords = append(keptRecords, record)
+			}
+			edits = append(edits, edit)
+			continue
+		}
+		keptRecords = append(keptRecords, record)
+	}
+	l.Records = keptRecords
+
+	if ip != "" {
+		return edits
+	}
+	var keptCNAMEs []LocalCNAME
+	for _, cname := range l.CNAMEs {
+		if containsHostname(cname.Domains, hostname) {
+			edit := LocalDNSEdit{Setting: "cnameRecords", Old: cname.stored()}
+			cname.Domains = slices.DeleteFunc(slices.Clone(cname.Domains), func(d string) bool {
+				return strings.EqualFold(strings.TrimSuffix(d, "."), hostname)
+			})
+			cname.entry = ""
+			if len(cname.Domains) > 0 && !l.hasCNAMEEntry(cname.String()) {
+				edit.New = cname.String()
+				keptCNAMEs = append(keptCNAMEs, cname)
+			}
+			edits = append(edits, edit)
+			continue
+		}
+		keptCNAMEs = append(keptCNAMEs, cname)
+	}
+	l.CNAMEs = keptCNAMEs
+	return edits
+}
+
+// stored returns the record as Pi-hole stores it, which may differ from
+// String in spacing
+func (r LocalDNSRecord) stored() string {
+	if r.entry != "" {
+		return r.entry
+	}
+	return r.String()
+}
+
+// stored returns the CNAME as Pi-hole stores it, which may differ from
+// String in spacing
+func (c LocalCNAME) stored() string {
+	if c.entry != "" {
+		return c.entry
+	}
+	return c.String()
+}
+
+// hasHostsEntry reports whether dns.hosts already holds entry, so that an
+// edit does not add it a second time
+func (l *LocalDNS) hasHostsEntry(entry string) bool {
+	return slices.ContainsFunc(l.Records, func(r LocalDNSRecord) bool { return r.stored() == entry })
+}
+
+// hasCNAMEEntry reports whether dns.cnameRecords already holds entry
+func (l *LocalDNS) hasCNAMEEntry(entry string) bool {
+	return slices.ContainsFunc(l.CNAMEs, func(c LocalCNAME) bool { return c.stored() == entry })
+}
+
+func containsHostname(hostnames []string, hostname string) bool {
+	return slices.ContainsFunc(hostnames, func(h string) bool {
+		return strings.EqualFold(strings.TrimSuffix(h, "."), hostname)
+	})
+}
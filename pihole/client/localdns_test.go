@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestParseLocalCNAME(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  string
+		ok    bool
+	}{
+		{"nas.lan,server.lan", "nas.lan,server.lan", true},
+		{"nas.lan, server.lan,300", "nas.lan,server.lan,300", true},
+		{"a.lan,b.lan,server.lan", "a.lan,b.lan,server.lan", true},
+		{"nas.lan", "", false},
+	}
+	for _, tt := range tests {
+		cname, ok := parseLocalCNAME(tt.entry)
+		if ok != tt.ok {
+			t.Errorf("parseLocalCNAME(%q) ok = %v, want %v", tt.entry, ok, tt.ok)
+			continue
+		}
+		if ok && cname.String() != tt.want {
+			t.Errorf("parseLocalCNAME(%q) = %q, want %q", tt.entry, cname.String(), tt.want)
+		}
+	}
+}
+
+func TestLocalDNSConflicts(t *testing.T) {
+	l := &LocalDNS{
+		Records: []LocalDNSRecord{
+			{IP: "192.168.1.10", Hostnames: []string{"nas.lan", "files.lan"}},
+			{IP: "fd00::10", Hostnames: []string{"nas.lan"}},
+		},
+		CNAMEs: []LocalCNAME{
+			{Domains: []string{"media.lan"}, Target: "nas.lan"},
+			{Domains: []string{"tv.lan"}, Target: "media.lan"},
+		},
+	}
+
+	recordTests := []struct {
+		ip, hostname string
+		wantErr      bool
+	}{
+		{"192.168.1.10", "nas.lan", true},
+		{"192.168.1.11", "nas.lan", true},
+		{"fd00::11", "files.lan", false},
+		{"192.168.1.12", "media.lan", true},
+		{"192.168.1.12", "printer.lan", false},
+	}
+	for _, tt := range recordTests {
+		if err := l.CheckRecord(tt.ip, tt.hostname); (err != nil) != tt.wantErr {
+			t.Errorf("CheckRecord(%q, %q) error = %v, wantErr %v", tt.ip, tt.hostname, err, tt.wantErr)
+		}
+	}
+
+	cnameTests := []struct {
+		domain, target string
+		wantErr        bool
+	}{
+		{"photos.lan", "nas.lan", false},
+		{"photos.lan", "photos.lan", true},
+		{"media.lan", "files.lan", true},
+		{"files.lan", "nas.lan", true},
+		{"nas2.lan", "tv.lan", false},
+		{"nas.example", "tv.lan", false},
+	}
+	for _, tt := range cnameTests {
+		if err := l.CheckCNAME(tt.domain, tt.target); (err != nil) != tt.wantErr {
+			t.Errorf("CheckCNAME(%q, %q) error = %v, wantErr %v", tt.domain, tt.target, err, tt.wantErr)
+		}
+	}
+
+	// A loop needs the new domain to be reachable from the target
+	loop := &LocalDNS{CNAMEs: []LocalCNAME{{Domains: []string{"b.lan"}, Target: "a.lan"}}}
+	if err := loop.CheckCNAME("a.lan", "b.lan"); err == nil {
+		t.Error("CheckCNAME(a.lan, b.lan) expected a loop error")
+	}
+
+	edits := l.RemoveHostname("nas.lan", "")
+	want := []LocalDNSEdit{
+		{Setting: "hosts", Old: "192.168.1.10 nas.lan files.lan", New: "192.168.1.10 files.lan"},
+		{Setting: "hosts", Old: "fd00::10 nas.lan"},
+	}
+	if !slices.Equal(edits, want) {
+		t.Errorf("RemoveHostname(nas.lan) = %v, want %v", edits, want)
+	}
+	if len(l.Records) != 1 || l.Records[0].String() != "192.168.1.10 files.lan" {
+		t.Errorf("RemoveHostname(nas.lan) left records %v", l.Records)
+	}
+}
+
+func TestLocalDNSEditsSingleEntries(t *testing.T) {
+	f, srv := newFakePihole(t)
+	hosts := []string{"192.168.1.10  nas.lan files.lan", "192.168.1.20 printer.lan # office", "not-an-entry"}
+	cnames := []string{"media.lan,nas.lan,300", "broken.lan,,nas.lan"}
+	var requests []string
+	f.mux.HandleFunc("GET /api/config/dns", func(w http.ResponseWriter, r *http.Request) {
+		var res dnsConfigBody
+		res.Config.DNS.Hosts = &hosts
+		res.Config.DNS.CnameRecords = &cnames
+		json.NewEncoder(w).Encode(res)
+	})
+	for _, pattern := range []string{"PUT /api/config/dns/{setting}/{entry}", "DELETE /api/config/dns/{setting}/{entry}"} {
+		f.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.PathValue("setting")+" "+r.PathValue("entry"))
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	ctx := context.Background()
+
+	c, err := NewClient(ctx, srv.URL+"/api", Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	local, err := c.GetLocalDNS(ctx)
+	if err != nil {
+		t.Fatalf("GetLocalDNS() error = %v", err)
+	}
+	if len(local.Records) != 1 || len(local.CNAMEs) != 1 {
+		t.Errorf("parsed %v and %v, want one record and one CNAME", local.Records, local.CNAMEs)
+	}
+	if !slices.Equal(local.UnparsedHosts, hosts[1:]) || !slices.Equal(local.UnparsedCNAMEs, cnames[1:]) {
+		t.Errorf("unparsed %v and %v, want %v and %v", local.UnparsedHosts, local.UnparsedCNAMEs, hosts[1:], cnames[1:])
+	}
+
+	if err := c.AddLocalCNAME(ctx, LocalCNAME{Domains: []string{"photos.lan"}, Target: "nas.lan"}); err != nil {
+		t.Fatalf("AddLocalCNAME() error = %v", err)
+	}
+	for _, edit := range local.RemoveHostname("nas.lan", "192.168.1.10") {
+		if err := c.EditLocalDNS(ctx, edit); err != nil {
+			t.Fatalf("EditLocalDNS(%v) error = %v", edit, err)
+		}
+	}
+	// The old entry is deleted exactly as stored, and nothing else is written
+	want := []string{
+		"PUT cnameRecords photos.lan,nas.lan",
+		"PUT hosts 192.168.1.10 files.lan",
+		"DELETE hosts 192.168.1.10  nas.lan files.lan",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("sent %q, want %q", requests, want)
+	}
+}
@@ -3,6 +3,9 @@ package client
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -13,79 +16,173 @@ type DNSQuery struct {
 	Domain string
 }
 
+// QueryLogEntry is a single query from Pi-hole's query log
+type QueryLogEntry struct {
+	Id       int        `json:"id"`
+	Time     float64    `json:"time"`
+	Type     string     `json:"type"`
+	Status   string     `json:"status"`
+	Dnssec   string     `json:"dnssec"`
+	Domain   string     `json:"domain"`
+	Upstream *string    `json:"upstream"`
+	Reply    QueryReply `json:"reply"`
+	Client   struct {
+		Ip   string  `json:"ip"`
+		Name *string `json:"name"`
+	} `json:"client"`
+	ListId *int     `json:"list_id"`
+	Ede    QueryEDE `json:"ede"`
+	Cname  *string  `json:"cname"`
+}
+
+type QueryReply struct {
+	Type string  `json:"type"`
+	Time float64 `json:"time"`
+}
+
+// QueryEDE is the Extended DNS Error attached to a reply, if any
+type QueryEDE struct {
+	Code int     `json:"code"`
+	Text *string `json:"text"`
+}
+
 type DNSQueries struct {
-	Queries []struct {
-		Id       int     `json:"id"`
-		Time     float64 `json:"time"`
-		Type     string  `json:"type"`
-		Status   string  `json:"status"`
-		Dnssec   string  `json:"dnssec"`
-		Domain   string  `json:"domain"`
-		Upstream *string `json:"upstream"`
-		Reply    struct {
-			Type string  `json:"type"`
-			Time float64 `json:"time"`
-		} `json:"reply"`
-		Client struct {
-			Ip   string      `json:"ip"`
-			Name interface{} `json:"name"`
-		} `json:"client"`
-		ListId *int `json:"list_id"`
-		Ede    struct {
-			Code int         `json:"code"`
-			Text interface{} `json:"text"`
-		} `json:"ede"`
-		Cname interface{} `json:"cname"`
-	} `json:"queries"`
-	Cursor          int     `json:"cursor"`
-	RecordsTotal    int     `json:"recordsTotal"`
-	RecordsFiltered int     `json:"recordsFiltered"`
-	Draw            int     `json:"draw"`
-	Took            float64 `json:"took"`
+	Queries         []QueryLogEntry `json:"queries"`
+	Cursor          int             `json:"cursor"`
+	RecordsTotal    int             `json:"recordsTotal"`
+	RecordsFiltered int             `json:"recordsFiltered"`
+	Draw            int             `json:"draw"`
+	Took            float64         `json:"took"`
 }
 
-func (c *Client) GetDNSQueriesForClient(ctx context.Context, clientIP string, until time.Time) ([]DNSQuery, error) {
-	var allQueries []DNSQuery
+// QueryFilter holds the filters of a query log search. Empty fields are not
+// filtered on. Domain, Client and Upstream accept '*' wildcards.
+type QueryFilter struct {
+	From     time.Time
+	Until    time.Time
+	Domain   string
+	Client   string
+	Upstream string
+	Type     string
+	Status   string
+	Reply    string
+	DNSSEC   string
+
+	// Cursor pins the result set to the queries that existed when the first
+	// page was fetched; pass the Cursor of the first response with an
+	// increasing Start to page through it
+	Cursor int
+	Start  int
+	Length int
 
-	untilUnix := float64(until.Unix())
-	start := 0
-	length := 100
+	// Disk searches the on-disk database instead of the in-memory log, which
+	// only covers the last 24 hours
+	Disk bool
+}
+
+func (f QueryFilter) values() url.Values {
+	v := url.Values{}
+	if !f.From.IsZero() {
+		v.Set("from", strconv.FormatInt(f.From.Unix(), 10))
+	}
+	if !f.Until.IsZero() {
+		v.Set("until", strconv.FormatInt(f.Until.Unix(), 10))
+	}
+	if f.Domain != "" {
+		v.Set("domain", f.Domain)
+	}
+	if f.Client != "" {
+		if net.ParseIP(f.Client) != nil {
+			v.Set("client_ip", f.Client)
+		} else {
+			v.Set("client_name", f.Client)
+		}
+	}
+	if f.Upstream != "" {
+		v.Set("upstream", f.Upstream)
+	}
+	if f.Type != "" {
+		v.Set("type", f.Type)
+	}
+	if f.Status != "" {
+		v.Set("status", f.Status)
+	}
+	if f.Reply != "" {
+		v.Set("reply", f.Reply)
+	}
+	if f.DNSSEC != "" {
+		v.Set("dnssec", f.DNSSEC)
+	}
+	if f.Cursor > 0 {
+		v.Set("cursor", strconv.Itoa(f.Cursor))
+	}
+	if f.Start > 0 {
+		v.Set("start", strconv.Itoa(f.Start))
+	}
+	if f.Length > 0 {
+		v.Set("length", strconv.Itoa(f.Length))
+	}
+	if f.Disk {
+		v.Set("disk", "true")
+	}
+	return v
+}
+
+// SearchQueries returns a page of the query log matching filter, newest first
+func (c *Client) SearchQueries(ctx context.Context, filter QueryFilter) (*DNSQueries, error) {
+	var res DNSQueries
+	err := c.getJSON(ctx, "queries?"+filter.values().Encode(), &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search queries: %w", err)
+	}
+	return &res, nil
+}
+
+// queryPageSize is the number of queries requested per page when walking the query log
+const queryPageSize = 1000
+
+// GetDNSQueriesForClient walks the queries made by clientIP between from and
+// until, newest first, and passes each one to fn. Paging is pinned to the
+// cursor of the first page so queries arriving mid-walk do not shift the
+// pages. The walk stops at the first error returned by fn or when ctx is done.
+func (c *Client) GetDNSQueriesForClient(ctx context.Context, clientIP string, from, until time.Time, fn func(DNSQuery) error) error {
+	filter := QueryFilter{
+		From:   from,
+		Until:  until,
+		Client: clientIP,
+		Length: queryPageSize,
+		Disk:   from.Before(time.Now().Add(-HistoryWindow)),
+	}
 
 	for {
-		var res DNSQueries
-		url := fmt.Sprintf("queries?client_ip=%s&start=%d&length=%d", clientIP, start, length)
-		err := c.getJSON(ctx, url, &res)
-		if err != nil {
-			return nil, fmt.Errorf("error getting dns queries for the client: %w", err)
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		// If no queries returned, we're done
-		if len(res.Queries) == 0 {
-			break
+		res, err := c.SearchQueries(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("error getting dns queries for the client: %w", err)
 		}
 
-		// Process queries and stop if we reach the until timestamp
 		for _, query := range res.Queries {
-			if query.Time < untilUnix {
-				// Reached queries older than until timestamp, stop pagination
-				return allQueries, nil
-			}
-			allQueries = append(allQueries, DNSQuery{
+			err := fn(DNSQuery{
 				Time:   time.Unix(int64(query.Time), 0),
 				Type:   query.Type,
 				Status: query.Status,
 				Domain: query.Domain,
 			})
+			if err != nil {
+				return err
+			}
 		}
 
-		// Update pagination parameters
-		start += length
-
-		// If we've fetched all records, stop
-		if start >= res.RecordsFiltered {
-			break
+		// Keep paging through the result set of the first page
+		if filter.Cursor == 0 {
+			filter.Cursor = res.Cursor
+		}
+		filter.Start += len(res.Queries)
+		if len(res.Queries) == 0 || filter.Start >= res.RecordsFiltered {
+			return nil
 		}
 	}
-
-	return allQueries, nil
 }
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveQueryLog serves total queries in pages, newest first. Each request
+// inserts a new query at the head of the log to check paging stays pinned to
+// the cursor of the first page.
+func serveQueryLog(t *testing.T, f *fakePihole, total int, requests *atomic.Int32) {
+	f.mux.HandleFunc("GET /api/queries", func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		q := r.URL.Query()
+		if q.Get("client_ip") != "192.168.1.20" || q.Get("from") == "" || q.Get("until") == "" {
+			t.Errorf("unexpected query filters: %s", r.URL.RawQuery)
+		}
+
+		newest := total + n
+		cursor := newest
+		if q.Get("cursor") != "" {
+			cursor, _ = strconv.Atoi(q.Get("cursor"))
+		}
+		start, _ := strconv.Atoi(q.Get("start"))
+		length, _ := strconv.Atoi(q.Get("length"))
+
+		// IDs run from 1 to cursor, the pinned result set is the oldest total queries
+		res := DNSQueries{Cursor: cursor, RecordsFiltered: total, Queries: []QueryLogEntry{}}
+		for id := cursor - start; id > cursor-start-length && id > cursor-total; id-- {
+			res.Queries = append(res.Queries, QueryLogEntry{Id: id, Time: float64(id), Domain: "d" + strconv.Itoa(id)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	})
+}
+
+func TestGetDNSQueriesForClientPagesWithCursor(t *testing.T) {
+	f, srv := newFakePihole(t)
+	var requests atomic.Int32
+	serveQueryLog(t, f, 2500, &requests)
+	ctx := context.Background()
+
+	c, err := NewClient(ctx, srv.URL+"/api", Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	seen := map[string]bool{}
+	err = c.GetDNSQueriesForClient(ctx, "192.168.1.20", time.Now().Add(-time.Hour), time.Now(), func(q DNSQuery) error {
+		if seen[q.Domain] {
+			t.Errorf("query %s seen twice", q.Domain)
+		}
+		seen[q.Domain] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GetDNSQueriesForClient() error = %v", err)
+	}
+	if len(seen) != 2500 {
+		t.Errorf("got %d queries, want 2500", len(seen))
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("got %d requests, want 3", n)
+	}
+}
+
+func TestGetDNSQueriesForClientStopsOnCancel(t *testing.T) {
+	f, srv := newFakePihole(t)
+	var requests atomic.Int32
+	serveQueryLog(t, f, 5000, &requests)
+
+	c, err := NewClient(context.Background(), srv.URL+"/api", Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = c.GetDNSQueriesForClient(ctx, "192.168.1.20", time.Now().Add(-time.Hour), time.Now(), func(q DNSQuery) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetDNSQueriesForClient() error = %v, want context.Canceled", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests after cancel, want 1", n)
+	}
+}
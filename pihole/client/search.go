@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// GravityMatch is an adlist that contains a searched domain
+type GravityMatch struct {
+	Domain string `json:"domain"`
+	List
+}
+
+// DomainSearch holds every exact rule, regex rule and adlist matching a domain
+type DomainSearch struct {
+	Domains []DomainRule   `json:"domains"`
+	Gravity []GravityMatch `json:"gravity"`
+}
+
+type domainSearchResponse struct {
+	Search DomainSearch `json:"search"`
+	Took   float64      `json:"took"`
+}
+
+// SearchDomain returns the domain rules and adlists that match domain exactly
+// or, for regex rules, by pattern
+func (c *Client) SearchDomain(ctx context.Context, domain string) (*DomainSearch, error) {
+	var res domainSearchResponse
+	// N caps the matches returned per list type, well above what a single domain hits
+	err := c.getJSON(ctx, fmt.Sprintf("search/%s?partial=false&N=100", url.PathEscape(domain)), &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for domain %s: %w", domain, err)
+	}
+	return &res.Search, nil
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+type APISession struct {
+	Id             int      `json:"id"`
+	CurrentSession bool     `json:"current_session"`
+	Valid          bool     `json:"valid"`
+	App            bool     `json:"app"`
+	Cli            bool     `json:"cli"`
+	LoginAt        UnixTime `json:"login_at"`
+	LastActive     UnixTime `json:"last_active"`
+	ValidUntil     UnixTime `json:"valid_until"`
+	RemoteAddr     string   `json:"remote_addr"`
+	UserAgent      *string  `json:"user_agent"`
+	XForwardedFor  *string  `json:"x_forwarded_for"`
+	Tls            struct {
+		Login bool `json:"login"`
+		Mixed bool `json:"mixed"`
+	} `json:"tls"`
+}
+
+type APISessions struct {
+	Sessions []APISession `json:"sessions"`
+	Took     float64      `json:"took"`
+}
+
+// GetAPISessions lists the API sessions currently known to Pi-hole. The session
+// used by this client is marked with CurrentSession.
+func (c *Client) GetAPISessions(ctx context.Context) (*APISessions, error) {
+	var res APISessions
+	err := c.getJSON(ctx, "auth/sessions", &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api sessions: %w", err)
+	}
+	return &res, nil
+}
+
+// DeleteAPISession revokes the API session with the given ID
+func (c *Client) DeleteAPISession(ctx context.Context, id int) error {
+	err := c.deleteJSON(ctx, fmt.Sprintf("auth/session/%d", id))
+	if err != nil {
+		return fmt.Errorf("failed to delete api session %d: %w", id, err)
+	}
+	return nil
+}
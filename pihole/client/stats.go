@@ -24,3 +24,45 @@ func (c *Client) GetTopActiveClientsByUsage(ctx context.Context, topN int) (*Top
 	}
 	return &res, nil
 }
+
+// Summary is Pi-hole's overview of query activity since FTL started, or over
+// the last 24 hours once FTL has been running that long
+type Summary struct {
+	Queries QuerySummary  `json:"queries"`
+	Clients ClientSummary `json:"clients"`
+	Gravity GravityStatus `json:"gravity"`
+	Took    float64       `json:"took"`
+}
+
+type QuerySummary struct {
+	Total          int            `json:"total"`
+	Blocked        int            `json:"blocked"`
+	PercentBlocked float64        `json:"percent_blocked"`
+	UniqueDomains  int            `json:"unique_domains"`
+	Forwarded      int            `json:"forwarded"`
+	Cached         int            `json:"cached"`
+	Frequency      float64        `json:"frequency"`
+	Types          map[string]int `json:"types"`
+	Status         map[string]int `json:"status"`
+	Replies        map[string]int `json:"replies"`
+}
+
+type ClientSummary struct {
+	Active int `json:"active"`
+	Total  int `json:"total"`
+}
+
+// GravityStatus describes the domains currently on gravity
+type GravityStatus struct {
+	DomainsBeingBlocked int      `json:"domains_being_blocked"`
+	LastUpdate          UnixTime `json:"last_update"`
+}
+
+func (c *Client) GetSummary(ctx context.Context) (*Summary, error) {
+	var res Summary
+	err := c.getJSON(ctx, "stats/summary", &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summary: %w", err)
+	}
+	return &res, nil
+}
@@ -0,0 +1,57 @@
+package client
+
+import "strings"
+
+// QueryCategory groups FTL query statuses by how the query was answered
+type QueryCategory string
+
+const (
+	CategoryForwarded       QueryCategory = "forwarded"
+	CategoryCached          QueryCategory = "cached"
+	CategoryBlockedGravity  QueryCategory = "blocked_gravity"
+	CategoryBlockedRegex    QueryCategory = "blocked_regex"
+	CategoryBlockedDenylist QueryCategory = "blocked_denylist"
+	CategoryBlockedUpstream QueryCategory = "blocked_upstream"
+	CategoryBlockedSpecial  QueryCategory = "blocked_special"
+	CategoryInProgress      QueryCategory = "in_progress"
+	CategoryUnknown         QueryCategory = "unknown"
+)
+
+// statusCategories maps every FTL query status to its category. The *_CNAME
+// statuses are blocks found while following a CNAME chain ("deep CNAME
+// inspection"), and count towards the list that matched.
+var statusCategories = map[string]QueryCategory{
+	"FORWARDED":              CategoryForwarded,
+	"RETRIED":                CategoryForwarded,
+	"RETRIED_DNSSEC":         CategoryForwarded,
+	"CACHE":                  CategoryCached,
+	"CACHE_STALE":            CategoryCached,
+	"GRAVITY":                CategoryBlockedGravity,
+	"GRAVITY_CNAME":          CategoryBlockedGravity,
+	"REGEX":                  CategoryBlockedRegex,
+	"REGEX_CNAME":            CategoryBlockedRegex,
+	"DENYLIST":               CategoryBlockedDenylist,
+	"DENYLIST_CNAME":         CategoryBlockedDenylist,
+	"EXTERNAL_BLOCKED_IP":    CategoryBlockedUpstream,
+	"EXTERNAL_BLOCKED_NULL":  CategoryBlockedUpstream,
+	"EXTERNAL_BLOCKED_NXRA":  CategoryBlockedUpstream,
+	"EXTERNAL_BLOCKED_EDE15": CategoryBlockedUpstream,
+	"SPECIAL_DOMAIN":         CategoryBlockedSpecial,
+	"DBBUSY":                 CategoryBlockedSpecial,
+	"IN_PROGRESS":            CategoryInProgress,
+	"UNKNOWN":                CategoryUnknown,
+}
+
+// ClassifyStatus returns the category of an FTL query status. Unrecognized
+// statuses are reported as unknown.
+func ClassifyStatus(status string) QueryCategory {
+	if category, ok := statusCategories[strings.ToUpper(status)]; ok {
+		return category
+	}
+	return CategoryUnknown
+}
+
+// Blocked reports whether queries in the category were blocked, by Pi-hole or upstream
+func (c QueryCategory) Blocked() bool {
+	return strings.HasPrefix(string(c), "blocked_")
+}
@@ -0,0 +1,29 @@
+package client
+
+import "testing"
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status  string
+		want    QueryCategory
+		blocked bool
+	}{
+		{"FORWARDED", CategoryForwarded, false},
+		{"RETRIED_DNSSEC", CategoryForwarded, false},
+		{"CACHE_STALE", CategoryCached, false},
+		{"GRAVITY", CategoryBlockedGravity, true},
+		{"GRAVITY_CNAME", CategoryBlockedGravity, true},
+		{"regex", CategoryBlockedRegex, true},
+		{"DENYLIST_CNAME", CategoryBlockedDenylist, true},
+		{"EXTERNAL_BLOCKED_NXRA", CategoryBlockedUpstream, true},
+		{"SPECIAL_DOMAIN", CategoryBlockedSpecial, true},
+		{"IN_PROGRESS", CategoryInProgress, false},
+		{"SOMETHING_NEW", CategoryUnknown, false},
+	}
+	for _, tt := range tests {
+		got := ClassifyStatus(tt.status)
+		if got != tt.want || got.Blocked() != tt.blocked {
+			t.Errorf("ClassifyStatus(%q) = %q (blocked %v), want %q (blocked %v)", tt.status, got, got.Blocked(), tt.want, tt.blocked)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_totpCode(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B (SHA1), truncated to 6 digits.
+	// The secret is the ASCII string "12345678901234567890" in base32.
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		want int
+	}{
+		{59, 287082},
+		{1111111109, 81804},
+		{1111111111, 50471},
+		{1234567890, 5924},
+		{2000000000, 279037},
+		{20000000000, 353130},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("totpCode() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %06d, want %06d", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func Test_decodeTOTPSecret(t *testing.T) {
+	want, err := decodeTOTPSecret("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	if err != nil {
+		t.Fatalf("decodeTOTPSecret() error = %v", err)
+	}
+	got, err := decodeTOTPSecret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	if err != nil {
+		t.Fatalf("decodeTOTPSecret() error = %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("decodeTOTPSecret() = %q, want %q", got, want)
+	}
+	if _, err := decodeTOTPSecret("not base32!"); err == nil {
+		t.Error("decodeTOTPSecret() expected error for invalid secret")
+	}
+}
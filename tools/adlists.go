@@ -0,0 +1,371 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type adlistInfo struct {
+	Instance       string `json:"instance"`
+	Address        string `json:"address"`
+	Type           string `json:"type"`
+	Comment        string `json:"comment,omitempty"`
+	Enabled        bool   `json:"enabled"`
+	Groups         []int  `json:"groups"`
+	Domains        int    `json:"domains"`
+	InvalidDomains int    `json:"invalid_domains"`
+	Status         string `json:"status"`
+	DateUpdated    string `json:"date_updated,omitempty"`
+}
+
+type adlistsResponse struct {
+	Instances      []string          `json:"instances"`
+	InstanceErrors map[string]string `json:"instance_errors,omitempty"`
+	TotalLists     int               `json:"total_lists"`
+	Lists          []adlistInfo      `json:"lists"`
+}
+
+type adlistItemResult struct {
+	Address  string `json:"address"`
+	Instance string `json:"instance"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+type addAdlistsResponse struct {
+	Instances      []string           `json:"instances"`
+	InstanceErrors map[string]string  `json:"instance_errors,omitempty"`
+	Results        []adlistItemResult `json:"results"`
+	Note           string             `json:"note"`
+}
+
+type gravitySummaryInfo struct {
+	Instance       string   `json:"instance"`
+	Completed      bool     `json:"completed"`
+	GravityDomains int      `json:"gravity_domains"`
+	UniqueDomains  int      `json:"unique_domains"`
+	FailedLists    []string `json:"failed_lists"`
+}
+
+type gravityResponse struct {
+	Instances      []string             `json:"instances"`
+	InstanceErrors map[string]string    `json:"instance_errors,omitempty"`
+	DurationSecs   int                  `json:"duration_seconds"`
+	Summaries      []gravitySummaryInfo `json:"summaries"`
+}
+
+// listStatusNames describes the status Pi-hole records for a list after a gravity run
+var listStatusNames = map[int]string{
+	client.ListStatusUnknown:   "unknown",
+	client.ListStatusUpdated:   "updated",
+	client.ListStatusUnchanged: "unchanged",
+	client.ListStatusCached:    "download failed, using cached copy",
+	client.ListStatusFailed:    "download failed",
+}
+
+type listAdlistsInput struct {
+	Type     string `json:"type,omitempty" jsonschema:"Only list subscriptions of this type (default: both)" enum:"block,allow"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerListAdlists registers the tool for listing subscribed adlists
+func (r *Registry) registerListAdlists(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "list_adlists",
+		Description: "List the blocklist/allowlist subscriptions Pi-hole builds gravity from, with how many domains each contributed and whether the last download succeeded",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleListAdlists)
+}
+
+// handleListAdlists handles requests for the list_adlists tool
+func (r *Registry) handleListAdlists(ctx context.Context, request *mcp.CallToolRequest, args listAdlistsInput) (*mcp.CallToolResult, *adlistsResponse, error) {
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) ([]client.List, error) {
+		return c.GetLists(ctx, args.Type)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to list adlists: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := adlistsResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		Lists:          []adlistInfo{},
+	}
+	for _, res := range succeeded {
+		for _, l := range res.Value {
+			info := adlistInfo{
+				Instance:       res.Instance,
+				Address:        l.Address,
+				Type:           l.Type,
+				Enabled:        l.Enabled,
+				Groups:         l.Groups,
+				Domains:        l.Number,
+				InvalidDomains: l.InvalidDomains,
+				Status:         listStatusNames[l.Status],
+			}
+			if l.Comment != nil {
+				info.Comment = *l.Comment
+			}
+			if l.DateUpdated.Unix() > 0 {
+				info.DateUpdated = l.DateUpdated.Format(time.RFC3339)
+			}
+			response.Lists = append(response.Lists, info)
+		}
+	}
+	response.TotalLists = len(response.Lists)
+
+	return nil, &response, nil
+}
+
+type addAdlistInput struct {
+	Addresses []string `json:"addresses" jsonschema:"URLs of the lists to subscribe to" minItems:"1"`
+	Type      string   `json:"type,omitempty" jsonschema:"Whether the list is a blocklist or an allowlist (default: block)" default:"block" enum:"block,allow"`
+	Comment   *string  `json:"comment,omitempty" jsonschema:"Comment stored with the list"`
+	Groups    []int    `json:"groups,omitempty" jsonschema:"IDs of the groups the list applies to (default: [0], the Default group)"`
+	Enabled   *bool    `json:"enabled,omitempty" jsonschema:"Whether the list is active (default: true)"`
+	Instance  string   `json:"instance,omitempty"`
+}
+
+// registerAddAdlist registers the tool for subscribing to adlists
+func (r *Registry) registerAddAdlist(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "add_adlist",
+		Description: "Subscribe Pi-hole to one or more blocklists (or allowlists) by URL. The domains are only loaded after running update_gravity. Reports success per list.",
+		Annotations: writeAnnotations(false, true, false),
+	}, r.handleAddAdlist)
+}
+
+// handleAddAdlist handles requests for the add_adlist tool
+func (r *Registry) handleAddAdlist(ctx context.Context, request *mcp.CallToolRequest, args addAdlistInput) (*mcp.CallToolResult, *addAdlistsResponse, error) {
+	// Validate addresses are provided
+	var addresses []string
+	for _, address := range args.Addresses {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, nil, errors.New("addresses is required")
+	}
+
+	input := client.ListInput{
+		Comment: args.Comment,
+		Groups:  args.Groups,
+		Enabled: args.Enabled,
+	}
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*client.Lists, error) {
+		return c.AddLists(ctx, args.Type, addresses, input)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to add adlists: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := addAdlistsResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		Results:        []adlistItemResult{},
+		Note:           "Run update_gravity to load the domains of new lists",
+	}
+	anySuccess := false
+	for _, res := range succeeded {
+		if res.Value.Processed == nil {
+			continue
+		}
+		for _, item := range res.Value.Processed.Success {
+			anySuccess = true
+			response.Results = append(response.Results, adlistItemResult{
+				Address:  item.Item,
+				Instance: res.Instance,
+				Success:  true,
+			})
+		}
+		for _, item := range res.Value.Processed.Errors {
+			response.Results = append(response.Results, adlistItemResult{
+				Address:  item.Item,
+				Instance: res.Instance,
+				Error:    item.Error,
+			})
+		}
+	}
+
+	return &mcp.CallToolResult{IsError: !anySuccess}, &response, nil
+}
+
+type setAdlistEnabledInput struct {
+	Address  string `json:"address" jsonschema:"URL of the list, as shown by list_adlists"`
+	Enabled  bool   `json:"enabled" jsonschema:"true to enable the list, false to disable it"`
+	Type     string `json:"type,omitempty" jsonschema:"Whether the list is a blocklist or an allowlist (default: block)" default:"block" enum:"block,allow"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerSetAdlistEnabled registers the tool for enabling or disabling an adlist
+func (r *Registry) registerSetAdlistEnabled(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "set_adlist_enabled",
+		Description: "Enable or disable a subscribed blocklist/allowlist without removing it. Takes effect after the next update_gravity.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleSetAdlistEnabled)
+}
+
+// handleSetAdlistEnabled handles requests for the set_adlist_enabled tool
+func (r *Registry) handleSetAdlistEnabled(ctx context.Context, request *mcp.CallToolRequest, args setAdlistEnabledInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Validate address is provided
+	if args.Address == "" {
+		return nil, nil, errors.New("address is required")
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		// Keep the list's comment and groups, PUT replaces them otherwise
+		lists, err := c.GetLists(ctx, args.Type)
+		if err != nil {
+			return struct{}{}, err
+		}
+		for _, l := range lists {
+			if l.Address == args.Address {
+				_, err := c.UpdateList(ctx, args.Type, l.Address, client.ListInput{
+					Comment: l.Comment,
+					Groups:  l.Groups,
+					Enabled: &args.Enabled,
+				})
+				return struct{}{}, err
+			}
+		}
+		return struct{}{}, fmt.Errorf("no %s list with address %q", args.Type, args.Address)
+	})
+
+	action := "Disabled"
+	if args.Enabled {
+		action = "Enabled"
+	}
+	return r.changeResult(results, err,
+		fmt.Sprintf("%s list %s (run update_gravity to apply)", action, args.Address),
+		fmt.Sprintf("Failed to update list %s", args.Address),
+	)
+}
+
+type removeAdlistInput struct {
+	Address  string `json:"address" jsonschema:"URL of the list, as shown by list_adlists"`
+	Type     string `json:"type,omitempty" jsonschema:"Whether the list is a blocklist or an allowlist (default: block)" default:"block" enum:"block,allow"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerRemoveAdlist registers the tool for unsubscribing from an adlist
+func (r *Registry) registerRemoveAdlist(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "remove_adlist",
+		Description: "Unsubscribe Pi-hole from a blocklist/allowlist. Its domains stay on gravity until the next update_gravity.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleRemoveAdlist)
+}
+
+// handleRemoveAdlist handles requests for the remove_adlist tool
+func (r *Registry) handleRemoveAdlist(ctx context.Context, request *mcp.CallToolRequest, args removeAdlistInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Validate address is provided
+	if args.Address == "" {
+		return nil, nil, errors.New("address is required")
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		return struct{}{}, c.DeleteList(ctx, args.Type, args.Address)
+	})
+	return r.changeResult(results, err,
+		fmt.Sprintf("Removed list %s (run update_gravity to drop its domains)", args.Address),
+		fmt.Sprintf("Failed to remove list %s", args.Address),
+	)
+}
+
+// registerUpdateGravity registers the tool for rebuilding gravity
+func (r *Registry) registerUpdateGravity(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "update_gravity",
+		Description: "Update gravity: download all subscribed lists and rebuild Pi-hole's blocking database. This can take a few minutes; the output is streamed as progress notifications. Returns the number of domains on gravity and the lists that failed to download.",
+		Annotations: writeAnnotations(false, true, true),
+	}, r.handleUpdateGravity)
+}
+
+// handleUpdateGravity handles requests for the update_gravity tool
+func (r *Registry) handleUpdateGravity(ctx context.Context, request *mcp.CallToolRequest, args instanceInput) (*mcp.CallToolResult, *gravityResponse, error) {
+	names, err := r.resolveInstances(args.Instance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Forward gravity output as progress notifications if the client asked for them
+	var (
+		progressMu sync.Mutex
+		progress   float64
+	)
+	progressToken := request.Params.GetProgressToken()
+	notify := func(instance, line string) {
+		if progressToken == nil || request.Session == nil {
+			return
+		}
+		if len(names) > 1 {
+			line = fmt.Sprintf("[%s] %s", instance, line)
+		}
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		progress++
+		err := request.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+			ProgressToken: progressToken,
+			Progress:      progress,
+			Message:       line,
+		})
+		if err != nil {
+			r.logger.Warn("Failed to send progress notification", "error", err)
+		}
+	}
+
+	started := time.Now()
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, name string, c *client.Client) (*client.GravitySummary, error) {
+		return c.UpdateGravity(ctx, func(line string) {
+			notify(name, line)
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to update gravity: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := gravityResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		DurationSecs:   int(time.Since(started).Seconds()),
+	}
+	for _, res := range succeeded {
+		info := gravitySummaryInfo{
+			Instance:       res.Instance,
+			Completed:      res.Value.Completed,
+			GravityDomains: res.Value.GravityDomains,
+			UniqueDomains:  res.Value.UniqueDomains,
+			FailedLists:    res.Value.FailedLists,
+		}
+		if info.FailedLists == nil {
+			info.FailedLists = []string{}
+		}
+		response.Summaries = append(response.Summaries, info)
+	}
+
+	return nil, &response, nil
+}
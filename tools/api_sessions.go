@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type apiSessionInfo struct {
+	Instance       string `json:"instance"`
+	Id             int    `json:"id"`
+	CurrentSession bool   `json:"current_session"`
+	Valid          bool   `json:"valid"`
+	App            bool   `json:"app_password"`
+	Cli            bool   `json:"cli"`
+	RemoteAddr     string `json:"remote_addr"`
+	UserAgent      string `json:"user_agent,omitempty"`
+	XForwardedFor  string `json:"x_forwarded_for,omitempty"`
+	TLS            bool   `json:"tls"`
+	LoginAt        string `json:"login_at"`
+	LastActive     string `json:"last_active"`
+	IdleMins       int    `json:"idle_mins"`
+	ValidUntil     string `json:"valid_until"`
+}
+
+type apiSessionsResponse struct {
+	Instances      []string          `json:"instances"`
+	InstanceErrors map[string]string `json:"instance_errors,omitempty"`
+	TotalSessions  int               `json:"total_sessions"`
+	Sessions       []apiSessionInfo  `json:"sessions"`
+}
+
+// registerListAPISessions registers the tool for listing Pi-hole API sessions
+func (r *Registry) registerListAPISessions(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "list_api_sessions",
+		Description: "List the API sessions currently open on Pi-hole (who logged in, from where, when they were last active). Pi-hole only allows a limited number of concurrent sessions, so stale ones can be revoked with revoke_api_session. The session used by this server is marked current_session.",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleListAPISessions)
+}
+
+// handleListAPISessions handles requests for the list_api_sessions tool
+func (r *Registry) handleListAPISessions(ctx context.Context, request *mcp.CallToolRequest, args instanceInput) (*mcp.CallToolResult, *apiSessionsResponse, error) {
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*client.APISessions, error) {
+		return c.GetAPISessions(ctx)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to list API sessions: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := apiSessionsResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		Sessions:       []apiSessionInfo{},
+	}
+	for _, res := range succeeded {
+		for _, s := range res.Value.Sessions {
+			response.Sessions = append(response.Sessions, newAPISessionInfo(res.Instance, s))
+		}
+	}
+	response.TotalSessions = len(response.Sessions)
+
+	return nil, &response, nil
+}
+
+// newAPISessionInfo converts a Pi-hole API session into its tool representation
+func newAPISessionInfo(instance string, s client.APISession) apiSessionInfo {
+	info := apiSessionInfo{
+		Instance:       instance,
+		Id:             s.Id,
+		CurrentSession: s.CurrentSession,
+		Valid:          s.Valid,
+		App:            s.App,
+		Cli:            s.Cli,
+		RemoteAddr:     s.RemoteAddr,
+		TLS:            s.Tls.Login,
+		LoginAt:        s.LoginAt.Format(time.RFC3339),
+		LastActive:     s.LastActive.Format(time.RFC3339),
+		IdleMins:       int(time.Since(s.LastActive.Time).Minutes()),
+		ValidUntil:     s.ValidUntil.Format(time.RFC3339),
+	}
+	if s.UserAgent != nil {
+		info.UserAgent = *s.UserAgent
+	}
+	if s.XForwardedFor != nil {
+		info.XForwardedFor = *s.XForwardedFor
+	}
+	return info
+}
+
+type revokeAPISessionInput struct {
+	Id       int    `json:"id" jsonschema:"The ID of the session to revoke" minimum:"0"`
+	Instance string `json:"instance,omitempty" fanOut:"false"`
+}
+
+// registerRevokeAPISession registers the tool for revoking a Pi-hole API session
+func (r *Registry) registerRevokeAPISession(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "revoke_api_session",
+		Description: "Revoke (log out) a Pi-hole API session by its ID, as returned by list_api_sessions. The session used by this server cannot be revoked.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleRevokeAPISession)
+}
+
+// handleRevokeAPISession handles requests for the revoke_api_session tool
+func (r *Registry) handleRevokeAPISession(ctx context.Context, request *mcp.CallToolRequest, args revokeAPISessionInput) (*mcp.CallToolResult, *changeResponse, error) {
+	instance, piholeClient, err := r.singleInstance(args.Instance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Refuse to revoke our own session, the next tool call would only log in again
+	sessions, err := piholeClient.GetAPISessions(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to list API sessions: %v", err)
+	}
+	for _, s := range sessions.Sessions {
+		if s.Id == args.Id && s.CurrentSession {
+			return nil, nil, fmt.Errorf("Session %d is the session used by this server and cannot be revoked", s.Id)
+		}
+	}
+
+	if err := piholeClient.DeleteAPISession(ctx, args.Id); err != nil {
+		return nil, nil, fmt.Errorf("Failed to revoke API session: %v", err)
+	}
+
+	return nil, &changeResponse{
+		Message:   fmt.Sprintf("Revoked API session %d on %s", args.Id, instance),
+		Instances: []string{instance},
+	}, nil
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type blockingStatusInfo struct {
+	Instance       string `json:"instance"`
+	Blocking       string `json:"blocking"`
+	TimerRemaining *int   `json:"timer_remaining_seconds,omitempty"`
+	RevertsAt      string `json:"reverts_at,omitempty"`
+}
+
+type blockingStatusResponse struct {
+	Instances      []string             `json:"instances"`
+	InstanceErrors map[string]string    `json:"instance_errors,omitempty"`
+	Statuses       []blockingStatusInfo `json:"statuses"`
+}
+
+// registerGetBlockingStatus registers the tool for getting the global blocking state
+func (r *Registry) registerGetBlockingStatus(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "get_blocking_status",
+		Description: "Get whether Pi-hole blocking is currently enabled or disabled, and how long until a running timer reverts it",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleGetBlockingStatus)
+}
+
+// handleGetBlockingStatus handles requests for the get_blocking_status tool
+func (r *Registry) handleGetBlockingStatus(ctx context.Context, request *mcp.CallToolRequest, args instanceInput) (*mcp.CallToolResult, *blockingStatusResponse, error) {
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*client.BlockingStatus, error) {
+		return c.GetBlockingStatus(ctx)
+	})
+	return r.blockingStatusResult(results, err, "Failed to get blocking status")
+}
+
+type setBlockingInput struct {
+	Enabled      bool    `json:"enabled" jsonschema:"true to enable blocking, false to disable it"`
+	TimerSeconds float64 `json:"timer_seconds,omitempty" jsonschema:"Revert to the previous state after this many seconds (default: no timer)" minimum:"1" maximum:"86400"`
+	Confirm      bool    `json:"confirm,omitempty" jsonschema:"Must be true to disable blocking or to set a timer (which disables blocking again when enabling), confirming the user explicitly requested it"`
+	Instance     string  `json:"instance,omitempty"`
+}
+
+// registerSetBlocking registers the tool for enabling or disabling blocking
+func (r *Registry) registerSetBlocking(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "set_blocking",
+		Description: "Enable or disable Pi-hole blocking for the whole network, optionally only for a limited time (e.g. disable for 5 minutes while troubleshooting a broken site). Disabling, and enabling with a timer (blocking is disabled again when it runs out), require confirm=true; only do so when the user explicitly asked for it.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleSetBlocking)
+}
+
+// handleSetBlocking handles requests for the set_blocking tool
+func (r *Registry) handleSetBlocking(ctx context.Context, request *mcp.CallToolRequest, args setBlockingInput) (*mcp.CallToolResult, *blockingStatusResponse, error) {
+	// Disabling blocking affects the whole network, so it needs explicit
+	// confirmation. A timer reverts enabling to disabled when it runs out.
+	if (!args.Enabled || args.TimerSeconds > 0) && !args.Confirm {
+		return nil, nil, errors.New("Disabling blocking, or enabling it only for a limited time, requires confirm=true. Ask the user to confirm before disabling Pi-hole blocking.")
+	}
+
+	timer := time.Duration(args.TimerSeconds * float64(time.Second))
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*client.BlockingStatus, error) {
+		return c.SetBlocking(ctx, args.Enabled, timer)
+	})
+	return r.blockingStatusResult(results, err, "Failed to set blocking status")
+}
+
+// blockingStatusResult builds the tool result shared by get_blocking_status and set_blocking
+func (r *Registry) blockingStatusResult(results []instanceResult[*client.BlockingStatus], err error, failure string) (*mcp.CallToolResult, *blockingStatusResponse, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("%s: %s", failure, r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := &blockingStatusResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+	}
+	for _, res := range succeeded {
+		status := blockingStatusInfo{
+			Instance: res.Instance,
+			Blocking: res.Value.Blocking,
+		}
+		if res.Value.Timer != nil && *res.Value.Timer > 0 {
+			remaining := int(*res.Value.Timer)
+			status.TimerRemaining = &remaining
+			status.RevertsAt = time.Now().Add(time.Duration(*res.Value.Timer * float64(time.Second))).Format(time.RFC3339)
+		}
+		response.Statuses = append(response.Statuses, status)
+	}
+	return nil, response, nil
+}
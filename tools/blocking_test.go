@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestSetBlockingConfirm(t *testing.T) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/dns/blocking", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		var body struct {
+			Blocking bool     `json:"blocking"`
+			Timer    *float64 `json:"timer"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		state := "disabled"
+		if body.Blocking {
+			state = "enabled"
+		}
+		json.NewEncoder(w).Encode(map[string]any{"blocking": state, "timer": body.Timer})
+	})
+	c := newTestClient(t, mux)
+	r := NewRegistry([]Instance{{Name: "primary", Client: c}}, nil, nil, false, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name    string
+		args    setBlockingInput
+		wantErr bool
+	}{
+		{"enable", setBlockingInput{Enabled: true}, false},
+		{"disable", setBlockingInput{Enabled: false}, true},
+		{"disable confirmed", setBlockingInput{Enabled: false, Confirm: true}, false},
+		// Blocking is disabled again when the timer runs out
+		{"enable with timer", setBlockingInput{Enabled: true, TimerSeconds: 300}, true},
+		{"enable with timer confirmed", setBlockingInput{Enabled: true, TimerSeconds: 300, Confirm: true}, false},
+	}
+	for _, tt := range tests {
+		requests = 0
+		_, res, err := r.handleSetBlocking(context.Background(), nil, tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			if requests != 0 {
+				t.Errorf("%s: sent %d requests without confirmation", tt.name, requests)
+			}
+			continue
+		}
+		if requests != 1 || len(res.Statuses) != 1 {
+			t.Errorf("%s: sent %d requests, got %v", tt.name, requests, res)
+		}
+	}
+}
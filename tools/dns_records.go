@@ -2,114 +2,135 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/ajinux/pi-hole-mcp-server/dnsclient"
+	"github.com/miekg/dns"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type dnsRecordResponse struct {
-	Domain string   `json:"domain"`
-	A      []string `json:"a_records"`
-	AAAA   []string `json:"aaaa_records"`
-	NS     []string `json:"ns_records"`
-	MX     []string `json:"mx_records"`
-	TXT    []string `json:"txt_records"`
+	domainNames
+	// AddressName is the name A, AAAA, CNAME, SRV, HTTPS and SVCB records
+	// were looked up for, CNAMEChain leads from it to the name holding them
+	AddressName string            `json:"address_name"`
+	CNAMEChain  []dnsclient.CNAME `json:"cname_chain,omitempty"`
+	// RecordTypes are the types looked up, types without records are left out
+	RecordTypes []string                  `json:"record_types"`
+	A           []dnsclient.AddressRecord `json:"a_records,omitempty"`
+	AAAA        []dnsclient.AddressRecord `json:"aaaa_records,omitempty"`
+	CNAME       []dnsclient.CNAME         `json:"cname_records,omitempty"`
+	NS          []dnsclient.NSRecord      `json:"ns_records,omitempty"`
+	MX          []dnsclient.MXRecord      `json:"mx_records,omitempty"`
+	TXT         []dnsclient.TXTRecord     `json:"txt_records,omitempty"`
+	SOA         []dnsclient.SOARecord     `json:"soa_records,omitempty"`
+	CAA         []dnsclient.CAARecord     `json:"caa_records,omitempty"`
+	SRV         []dnsclient.SRVRecord     `json:"srv_records,omitempty"`
+	HTTPS       []dnsclient.SVCBRecord    `json:"https_records,omitempty"`
+	SVCB        []dnsclient.SVCBRecord    `json:"svcb_records,omitempty"`
+	DS          []dnsclient.DSRecord      `json:"ds_records,omitempty"`
+	DNSKEY      []dnsclient.DNSKEYRecord  `json:"dnskey_records,omitempty"`
+	PTR         []dnsclient.PTRRecord     `json:"ptr_records,omitempty"`
+	// Errors holds the lookups that failed by record type
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+type dnsRecordsInput struct {
+	Domain      string   `json:"domain" jsonschema:"The domain or subdomain to query (e.g., example.com or telemetry.vendor.com)"`
+	ApexOnly    bool     `json:"apex_only,omitempty" jsonschema:"Look up A, AAAA, CNAME, SRV, HTTPS and SVCB records at the registrable domain instead of the exact name given (default: false)"`
+	RecordTypes []string `json:"record_types,omitempty" enum:"A,AAAA,CNAME,NS,MX,TXT,SOA,CAA,SRV,HTTPS,SVCB,DS,DNSKEY,PTR" jsonschema:"Record types to look up (default: all). PTR looks up the reverse names of the A and AAAA records."`
+}
+
+// domainInput is the input of the domain intelligence tools
+type domainInput struct {
+	Domain string `json:"domain" jsonschema:"The domain or subdomain to query (e.g., example.com or api.example.com)"`
+}
+
+// domainNames shows which registrable domain the domain intelligence tools
+// looked up for the queried name
+type domainNames struct {
+	QueriedName string `json:"queried_name"`
+	// UnicodeName is the queried name with punycode labels decoded, set for IDNs
+	UnicodeName       string `json:"unicode_name,omitempty"`
+	RegistrableDomain string `json:"registrable_domain"`
+	PublicSuffix      string `json:"public_suffix"`
+}
+
+// registrableDomain resolves the registrable domain (eTLD+1) of a tool's domain
+// argument with the Public Suffix List, e.g. bbc.co.uk for www.bbc.co.uk
+func (r *Registry) registrableDomain(domain string) (domainNames, error) {
+	if domain == "" {
+		return domainNames{}, errors.New("domain is required")
+	}
+	name, err := r.suffixes.ParseDomain(domain)
+	if err != nil {
+		return domainNames{}, err
+	}
+	names := domainNames{
+		QueriedName:       name.Name,
+		RegistrableDomain: name.Registrable,
+		PublicSuffix:      name.PublicSuffix,
+	}
+	if name.Unicode != name.Name {
+		names.UnicodeName = name.Unicode
+	}
+	return names, nil
 }
 
 // registerDNSRecords registers the tool for getting DNS records for a domain
 func (r *Registry) registerDNSRecords(server *mcp.Server) {
-	server.AddTool(&mcp.Tool{
+	addTool(r, server, &mcp.Tool{
 		Name:        "get_domain_dns_records",
-		Description: "Get DNS records (A, AAAA, NS, MX, TXT) for a domain. Automatically extracts top-level domain if a subdomain is provided.",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"domain": map[string]interface{}{
-					"type":        "string",
-					"description": "The domain or subdomain to query (e.g., example.com or api.example.com)",
-				},
-			},
-			"required": []string{"domain"},
-		},
-	}, r.withLogging("get_domain_dns_records", r.handleDNSRecords))
+		Description: "Get DNS records for a domain as structured data with TTLs: A, AAAA, CNAME, NS, MX, TXT, SOA (serial, admin contact, timers), CAA (which CAs may issue), SRV, HTTPS/SVCB (ALPN, ECH and address hints), DS/DNSKEY (DNSSEC) and PTR (reverse names of the addresses). A, AAAA, CNAME, SRV, HTTPS and SVCB records are looked up for the exact name given, with the CNAME chain it resolves through. The other types are looked up at the registrable domain, using the Public Suffix List (e.g. telemetry.bbc.co.uk -> bbc.co.uk).",
+		Annotations: readOnlyAnnotations(true),
+	}, r.handleDNSRecords)
 }
 
 // handleDNSRecords handles requests for the get_domain_dns_records tool
-func (r *Registry) handleDNSRecords(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Parse arguments from JSON
-	var args struct {
-		Domain string `json:"domain"`
+func (r *Registry) handleDNSRecords(ctx context.Context, request *mcp.CallToolRequest, args dnsRecordsInput) (*mcp.CallToolResult, *dnsRecordResponse, error) {
+	names, err := r.registrableDomain(args.Domain)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Parse arguments
-	if len(request.Params.Arguments) > 0 {
-		if err := json.Unmarshal(request.Params.Arguments, &args); err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{
-					&mcp.TextContent{
-						Text: fmt.Sprintf("Failed to parse arguments: %v", err),
-					},
-				},
-			}, nil
-		}
+	addressName := names.QueriedName
+	if args.ApexOnly {
+		addressName = names.RegistrableDomain
 	}
-
-	// Validate domain is provided
-	if args.Domain == "" {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: "domain is required",
-				},
-			},
-		}, nil
+	var qtypes []uint16
+	for _, name := range args.RecordTypes {
+		qtypes = append(qtypes, dns.StringToType[name])
 	}
-
-	// Get DNS records (this will automatically strip to TLD)
-	records, err := dnsclient.GetAllRecords(args.Domain)
+	records, err := r.resolver.GetAllRecords(ctx, addressName, names.RegistrableDomain, qtypes...)
 	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to get DNS records: %v", err),
-				},
-			},
-		}, nil
+		return nil, nil, fmt.Errorf("Failed to get DNS records: %v", err)
 	}
 
 	// Build response
 	response := dnsRecordResponse{
-		Domain: records.Domain,
-		A:      records.A,
-		AAAA:   records.AAAA,
-		NS:     records.NS,
-		MX:     records.MX,
-		TXT:    records.TXT,
+		domainNames: names,
+		AddressName: addressName,
+		CNAMEChain:  records.CNAMEChain,
+		A:           records.A,
+		AAAA:        records.AAAA,
+		CNAME:       records.CNAME,
+		NS:          records.NS,
+		MX:          records.MX,
+		TXT:         records.TXT,
+		SOA:         records.SOA,
+		CAA:         records.CAA,
+		SRV:         records.SRV,
+		HTTPS:       records.HTTPS,
+		SVCB:        records.SVCB,
+		DS:          records.DS,
+		DNSKEY:      records.DNSKEY,
+		PTR:         records.PTR,
+		Errors:      records.Errors,
 	}
-
-	// Format response as JSON
-	resultJSON, err := json.MarshalIndent(response, "", "  ")
-	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to marshal response: %v", err),
-				},
-			},
-		}, nil
+	for _, qtype := range records.Types {
+		response.RecordTypes = append(response.RecordTypes, dns.TypeToString[qtype])
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return nil, &response, nil
 }
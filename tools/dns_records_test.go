@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ajinux/pi-hole-mcp-server/dnsclient"
+	"github.com/miekg/dns"
+)
+
+func TestRecordTypesEnum(t *testing.T) {
+	field, _ := reflect.TypeFor[dnsRecordsInput]().FieldByName("RecordTypes")
+	enum := strings.Split(field.Tag.Get("enum"), ",")
+
+	var want []string
+	for _, qtype := range dnsclient.RecordTypes {
+		want = append(want, dns.TypeToString[qtype])
+	}
+	if !slices.Equal(enum, want) {
+		t.Errorf("record_types enum = %v, want dnsclient.RecordTypes %v", enum, want)
+	}
+}
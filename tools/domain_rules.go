@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type domainRuleInfo struct {
+	Instance     string `json:"instance"`
+	Domain       string `json:"domain"`
+	Type         string `json:"type"`
+	Kind         string `json:"kind"`
+	Comment      string `json:"comment,omitempty"`
+	Groups       []int  `json:"groups"`
+	Enabled      bool   `json:"enabled"`
+	Id           int    `json:"id"`
+	DateAdded    string `json:"date_added"`
+	DateModified string `json:"date_modified"`
+}
+
+type domainRulesResponse struct {
+	Instances      []string          `json:"instances"`
+	InstanceErrors map[string]string `json:"instance_errors,omitempty"`
+	TotalRules     int               `json:"total_rules"`
+	Rules          []domainRuleInfo  `json:"rules"`
+}
+
+type domainRuleItemResult struct {
+	Domain   string `json:"domain"`
+	Instance string `json:"instance,omitempty"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+type domainRuleChangeResponse struct {
+	Instances      []string               `json:"instances"`
+	InstanceErrors map[string]string      `json:"instance_errors,omitempty"`
+	Type           string                 `json:"type"`
+	Kind           string                 `json:"kind"`
+	Succeeded      int                    `json:"succeeded"`
+	Failed         int                    `json:"failed"`
+	Results        []domainRuleItemResult `json:"results"`
+}
+
+// addDomainRulesInput is the input of the block_domain and allow_domain tools
+type addDomainRulesInput struct {
+	Domains  []string `json:"domains" jsonschema:"Domains (kind=exact) or regular expressions (kind=regex) to add" minItems:"1"`
+	Kind     string   `json:"kind,omitempty" jsonschema:"Whether the entries are exact domains or regular expressions (default: exact)" default:"exact" enum:"exact,regex"`
+	Comment  *string  `json:"comment,omitempty" jsonschema:"Comment stored with the rule, e.g. why it was added"`
+	Groups   []int    `json:"groups,omitempty" jsonschema:"IDs of the groups the rule applies to (default: [0], the Default group)"`
+	Enabled  *bool    `json:"enabled,omitempty" jsonschema:"Whether the rule is active (default: true)"`
+	Instance string   `json:"instance,omitempty"`
+}
+
+// registerBlockDomain registers the tool for adding domains to the deny list
+func (r *Registry) registerBlockDomain(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "block_domain",
+		Description: "Block one or more domains by adding them to Pi-hole's deny list, either as exact domains or as regular expressions. Reports success per domain.",
+		Annotations: writeAnnotations(false, true, false),
+	}, r.addDomainRulesHandler(client.DomainTypeDeny))
+}
+
+// registerAllowDomain registers the tool for adding domains to the allow list
+func (r *Registry) registerAllowDomain(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "allow_domain",
+		Description: "Allow one or more domains by adding them to Pi-hole's allow list, either as exact domains or as regular expressions. Allowed domains are never blocked, even if they appear on an adlist. Reports success per domain.",
+		Annotations: writeAnnotations(false, true, false),
+	}, r.addDomainRulesHandler(client.DomainTypeAllow))
+}
+
+// addDomainRulesHandler returns the handler of the block_domain or allow_domain tool
+func (r *Registry) addDomainRulesHandler(listType string) mcp.ToolHandlerFor[addDomainRulesInput, *domainRuleChangeResponse] {
+	return func(ctx context.Context, request *mcp.CallToolRequest, args addDomainRulesInput) (*mcp.CallToolResult, *domainRuleChangeResponse, error) {
+		// Validate entries locally so one bad regex does not fail the whole batch
+		response := &domainRuleChangeResponse{
+			Type:    listType,
+			Kind:    args.Kind,
+			Results: []domainRuleItemResult{},
+		}
+		var valid []string
+		for _, domain := range args.Domains {
+			domain = strings.TrimSpace(domain)
+			if args.Kind == client.DomainKindExact {
+				domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+			}
+			if domain == "" {
+				continue
+			}
+			if args.Kind == client.DomainKindRegex {
+				if err := client.ValidateRegex(domain); err != nil {
+					response.Results = append(response.Results, domainRuleItemResult{
+						Domain: domain,
+						Error:  err.Error(),
+					})
+					continue
+				}
+			}
+			valid = append(valid, domain)
+		}
+
+		if len(valid) > 0 {
+			input := client.DomainRuleInput{
+				Comment: args.Comment,
+				Groups:  args.Groups,
+				Enabled: args.Enabled,
+			}
+			results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*client.DomainRules, error) {
+				return c.AddDomainRules(ctx, listType, args.Kind, valid, input)
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			succeeded, instanceErrors := splitResults(results)
+			if len(succeeded) == 0 {
+				return nil, nil, fmt.Errorf("Failed to add domains to the %s list: %s", listType, r.formatInstanceErrors(instanceErrors))
+			}
+			response.Instances = instanceNames(succeeded)
+			response.InstanceErrors = instanceErrors
+
+			for _, res := range succeeded {
+				if res.Value.Processed == nil {
+					continue
+				}
+				for _, item := range res.Value.Processed.Success {
+					response.Results = append(response.Results, domainRuleItemResult{
+						Domain:   item.Item,
+						Instance: res.Instance,
+						Success:  true,
+					})
+				}
+				for _, item := range res.Value.Processed.Errors {
+					response.Results = append(response.Results, domainRuleItemResult{
+						Domain:   item.Item,
+						Instance: res.Instance,
+						Error:    item.Error,
+					})
+				}
+			}
+		}
+
+		for _, res := range response.Results {
+			if res.Success {
+				response.Succeeded++
+			} else {
+				response.Failed++
+			}
+		}
+
+		return &mcp.CallToolResult{IsError: response.Succeeded == 0}, response, nil
+	}
+}
+
+type listDomainRulesInput struct {
+	Type     string `json:"type,omitempty" jsonschema:"Only list rules of this list (default: both)" enum:"allow,deny"`
+	Kind     string `json:"kind,omitempty" jsonschema:"Only list rules of this kind (default: both)" enum:"exact,regex"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerListDomainRules registers the tool for listing allow/deny list entries
+func (r *Registry) registerListDomainRules(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "list_domain_rules",
+		Description: "List the exact domains and regular expressions on Pi-hole's allow and deny lists, with their comments, groups and enabled state",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleListDomainRules)
+}
+
+// handleListDomainRules handles requests for the list_domain_rules tool
+func (r *Registry) handleListDomainRules(ctx context.Context, request *mcp.CallToolRequest, args listDomainRulesInput) (*mcp.CallToolResult, *domainRulesResponse, error) {
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) ([]client.DomainRule, error) {
+		return c.GetDomainRules(ctx, args.Type, args.Kind)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to list domain rules: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := &domainRulesResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		Rules:          []domainRuleInfo{},
+	}
+	for _, res := range succeeded {
+		for _, rule := range res.Value {
+			info := domainRuleInfo{
+				Instance:     res.Instance,
+				Domain:       rule.Domain,
+				Type:         rule.Type,
+				Kind:         rule.Kind,
+				Groups:       rule.Groups,
+				Enabled:      rule.Enabled,
+				Id:           rule.Id,
+				DateAdded:    rule.DateAdded.Format(time.RFC3339),
+				DateModified: rule.DateModified.Format(time.RFC3339),
+			}
+			if rule.Comment != nil {
+				info.Comment = *rule.Comment
+			}
+			response.Rules = append(response.Rules, info)
+		}
+	}
+	response.TotalRules = len(response.Rules)
+
+	return nil, response, nil
+}
+
+type removeDomainRuleInput struct {
+	Domain   string `json:"domain" jsonschema:"The domain or regular expression to remove, exactly as listed by list_domain_rules"`
+	Type     string `json:"type" jsonschema:"The list the rule is on" enum:"allow,deny"`
+	Kind     string `json:"kind,omitempty" jsonschema:"Whether the rule is an exact domain or a regular expression (default: exact)" default:"exact" enum:"exact,regex"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerRemoveDomainRule registers the tool for removing an allow/deny list entry
+func (r *Registry) registerRemoveDomainRule(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "remove_domain_rule",
+		Description: "Remove an exact domain or regular expression from Pi-hole's allow or deny list",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleRemoveDomainRule)
+}
+
+// handleRemoveDomainRule handles requests for the remove_domain_rule tool
+func (r *Registry) handleRemoveDomainRule(ctx context.Context, request *mcp.CallToolRequest, args removeDomainRuleInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Normalize the domain the way block_domain and allow_domain stored it
+	args.Domain = strings.TrimSpace(args.Domain)
+	if args.Kind == client.DomainKindExact {
+		args.Domain = strings.TrimSuffix(strings.ToLower(args.Domain), ".")
+	}
+	if args.Domain == "" {
+		return nil, nil, errors.New("domain is required")
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		return struct{}{}, c.DeleteDomainRule(ctx, args.Type, args.Kind, args.Domain)
+	})
+	return r.changeResult(results, err,
+		fmt.Sprintf("Removed %s %s rule %q", args.Type, args.Kind, args.Domain),
+		fmt.Sprintf("Failed to remove %s %s rule %q", args.Type, args.Kind, args.Domain),
+	)
+}
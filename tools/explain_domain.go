@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type ruleMatch struct {
+	ID      int      `json:"id"`
+	Rule    string   `json:"rule"`
+	Type    string   `json:"type"`
+	Enabled bool     `json:"enabled"`
+	Groups  []string `json:"groups"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+type adlistMatch struct {
+	ID      int      `json:"id"`
+	Address string   `json:"address"`
+	Type    string   `json:"type"`
+	Enabled bool     `json:"enabled"`
+	Groups  []string `json:"groups"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+type explainedQuery struct {
+	Time       string               `json:"time"`
+	ClientIP   string               `json:"client_ip"`
+	ClientName string               `json:"client_name,omitempty"`
+	Type       string               `json:"type"`
+	Status     string               `json:"status"`
+	Category   client.QueryCategory `json:"category"`
+	ListID     *int                 `json:"list_id,omitempty"`
+	MatchedBy  string               `json:"matched_by,omitempty"`
+}
+
+type domainExplanation struct {
+	Instance      string           `json:"instance,omitempty"`
+	Verdict       string           `json:"verdict"`
+	ExactRules    []ruleMatch      `json:"exact_rules"`
+	RegexRules    []ruleMatch      `json:"regex_rules"`
+	Adlists       []adlistMatch    `json:"adlists"`
+	RecentQueries []explainedQuery `json:"recent_queries"`
+}
+
+type explainDomainResponse struct {
+	Instances      []string            `json:"instances"`
+	InstanceErrors map[string]string   `json:"instance_errors,omitempty"`
+	Domain         string              `json:"domain"`
+	Explanations   []domainExplanation `json:"explanations"`
+	Note           string              `json:"note"`
+}
+
+type explainDomainInput struct {
+	Domain   string `json:"domain" jsonschema:"The domain to explain (e.g. ads.example.com)"`
+	Recent   int    `json:"recent,omitempty" jsonschema:"Number of recent queries to include (default: 10, max: 100)" default:"10" minimum:"0" maximum:"100"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerExplainDomain registers the tool for explaining why a domain is blocked or allowed
+func (r *Registry) registerExplainDomain(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "explain_domain",
+		Description: "Explain why a domain is blocked or allowed: lists every matching exact rule, regex rule and adlist (with URL, groups and enabled state), and the most recent queries for the domain with the list that decided them. Use it when a site is broken or an ad gets through.",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleExplainDomain)
+}
+
+// handleExplainDomain handles requests for the explain_domain tool
+func (r *Registry) handleExplainDomain(ctx context.Context, request *mcp.CallToolRequest, args explainDomainInput) (*mcp.CallToolResult, *explainDomainResponse, error) {
+	// Validate domain
+	domain, err := client.NormalizeHostname(args.Domain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*domainExplanation, error) {
+		return explainDomain(ctx, c, domain, args.Recent)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to explain domain %s: %s", domain, r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := explainDomainResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		Domain:         domain,
+		Note:           "Rules and adlists only apply to clients in their groups; the verdict assumes a client in all of them",
+	}
+	for _, res := range succeeded {
+		res.Value.Instance = res.Instance
+		response.Explanations = append(response.Explanations, *res.Value)
+	}
+
+	return nil, &response, nil
+}
+
+// explainDomain collects the rules, adlists and recent queries for domain on one Pi-hole
+func explainDomain(ctx context.Context, c *client.Client, domain string, recent int) (*domainExplanation, error) {
+	search, err := c.SearchDomain(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := c.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groupNames := func(ids []int) []string {
+		names := make([]string, 0, len(ids))
+		for _, id := range ids {
+			names = append(names, groupName(groups, id))
+		}
+		return names
+	}
+
+	explanation := &domainExplanation{
+		ExactRules:    []ruleMatch{},
+		RegexRules:    []ruleMatch{},
+		Adlists:       []adlistMatch{},
+		RecentQueries: []explainedQuery{},
+	}
+	// list_id in the query log refers to a domain rule for rule-based
+	// statuses and to an adlist for gravity
+	rulesByID := make(map[int]string)
+	adlistsByID := make(map[int]string)
+
+	for _, rule := range search.Domains {
+		match := ruleMatch{
+			ID:      rule.Id,
+			Rule:    rule.Domain,
+			Type:    rule.Type,
+			Enabled: rule.Enabled,
+			Groups:  groupNames(rule.Groups),
+		}
+		if rule.Comment != nil {
+			match.Comment = *rule.Comment
+		}
+		if rule.Kind == client.DomainKindRegex {
+			explanation.RegexRules = append(explanation.RegexRules, match)
+		} else {
+			explanation.ExactRules = append(explanation.ExactRules, match)
+		}
+		rulesByID[rule.Id] = fmt.Sprintf("%s %s rule %s", rule.Kind, rule.Type, rule.Domain)
+	}
+	for _, list := range search.Gravity {
+		match := adlistMatch{
+			ID:      list.Id,
+			Address: list.Address,
+			Type:    list.Type,
+			Enabled: list.Enabled,
+			Groups:  groupNames(list.Groups),
+		}
+		if list.Comment != nil {
+			match.Comment = *list.Comment
+		}
+		explanation.Adlists = append(explanation.Adlists, match)
+		adlistsByID[list.Id] = fmt.Sprintf("%s list %s", list.Type, list.Address)
+	}
+	explanation.Verdict = domainVerdict(search)
+
+	if recent > 0 {
+		queries, err := c.SearchQueries(ctx, client.QueryFilter{Domain: domain, Length: recent})
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range queries.Queries {
+			category := client.ClassifyStatus(q.Status)
+			query := explainedQuery{
+				Time:     time.Unix(int64(q.Time), 0).Format(time.RFC3339),
+				ClientIP: q.Client.Ip,
+				Type:     q.Type,
+				Status:   q.Status,
+				Category: category,
+				ListID:   q.ListId,
+			}
+			if q.Client.Name != nil {
+				query.ClientName = *q.Client.Name
+			}
+			if q.ListId != nil {
+				if category == client.CategoryBlockedGravity {
+					query.MatchedBy = adlistsByID[*q.ListId]
+				} else {
+					query.MatchedBy = rulesByID[*q.ListId]
+				}
+			}
+			explanation.RecentQueries = append(explanation.RecentQueries, query)
+		}
+	}
+
+	return explanation, nil
+}
+
+// domainVerdict applies Pi-hole's order of precedence to the enabled matches:
+// exact allow, regex allow, exact deny, gravity (unless on an allowlist
+// subscription) and finally regex deny
+func domainVerdict(search *client.DomainSearch) string {
+	firstRule := func(kind, listType string) string {
+		for _, rule := range search.Domains {
+			if rule.Enabled && rule.Kind == kind && rule.Type == listType {
+				return rule.Domain
+			}
+		}
+		return ""
+	}
+	firstList := func(listType string) string {
+		for _, list := range search.Gravity {
+			if list.Enabled && list.Type == listType {
+				return list.Address
+			}
+		}
+		return ""
+	}
+
+	if rule := firstRule(client.DomainKindExact, client.DomainTypeAllow); rule != "" {
+		return "allowed by exact allow rule " + rule
+	}
+	if rule := firstRule(client.DomainKindRegex, client.DomainTypeAllow); rule != "" {
+		return "allowed by regex allow rule " + rule
+	}
+	if rule := firstRule(client.DomainKindExact, client.DomainTypeDeny); rule != "" {
+		return "blocked by exact deny rule " + rule
+	}
+	if list := firstList(client.ListTypeBlock); list != "" {
+		if allow := firstList(client.ListTypeAllow); allow != "" {
+			return "allowed by allowlist subscription " + allow + " (also on blocklist " + list + ")"
+		}
+		return "blocked by adlist " + list
+	}
+	if rule := firstRule(client.DomainKindRegex, client.DomainTypeDeny); rule != "" {
+		return "blocked by regex deny rule " + rule
+	}
+	return "not blocked: no enabled rule or adlist matches"
+}
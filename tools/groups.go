@@ -0,0 +1,435 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type groupInfo struct {
+	Instance string   `json:"instance"`
+	Id       int      `json:"id"`
+	Name     string   `json:"name"`
+	Comment  string   `json:"comment,omitempty"`
+	Enabled  bool     `json:"enabled"`
+	Clients  []string `json:"clients"`
+}
+
+type groupsResponse struct {
+	Instances      []string          `json:"instances"`
+	InstanceErrors map[string]string `json:"instance_errors,omitempty"`
+	Groups         []groupInfo       `json:"groups"`
+}
+
+type clientAssignmentInfo struct {
+	Instance string   `json:"instance"`
+	Client   string   `json:"client"`
+	Groups   []string `json:"groups"`
+	Warning  string   `json:"warning,omitempty"`
+}
+
+type clientAssignmentResponse struct {
+	Instances      []string               `json:"instances"`
+	InstanceErrors map[string]string      `json:"instance_errors,omitempty"`
+	Assignments    []clientAssignmentInfo `json:"assignments"`
+}
+
+// registerListGroups registers the tool for listing groups and their clients
+func (r *Registry) registerListGroups(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "list_groups",
+		Description: "List Pi-hole groups with their enabled state and the clients (IP, subnet, MAC, hostname) explicitly assigned to each. Clients not assigned to any group belong to the Default group.",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleListGroups)
+}
+
+// handleListGroups handles requests for the list_groups tool
+func (r *Registry) handleListGroups(ctx context.Context, request *mcp.CallToolRequest, args instanceInput) (*mcp.CallToolResult, *groupsResponse, error) {
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*client.GroupMembership, error) {
+		return c.GetGroupMembership(ctx)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to list groups: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := groupsResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		Groups:         []groupInfo{},
+	}
+	for _, res := range succeeded {
+		members := make(map[int][]string)
+		for _, c := range res.Value.Clients {
+			for _, id := range c.Groups {
+				members[id] = append(members[id], c.Client)
+			}
+		}
+
+		ids := make([]int, 0, len(res.Value.Groups))
+		for id := range res.Value.Groups {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			g := res.Value.Groups[id]
+			info := groupInfo{
+				Instance: res.Instance,
+				Id:       g.Id,
+				Name:     g.Name,
+				Enabled:  g.Enabled,
+				Clients:  members[id],
+			}
+			if info.Clients == nil {
+				info.Clients = []string{}
+			}
+			if g.Comment != nil {
+				info.Comment = *g.Comment
+			}
+			response.Groups = append(response.Groups, info)
+		}
+	}
+
+	return nil, &response, nil
+}
+
+type createGroupInput struct {
+	Name     string  `json:"name" jsonschema:"Name of the group"`
+	Comment  *string `json:"comment,omitempty" jsonschema:"Description of the group"`
+	Enabled  *bool   `json:"enabled,omitempty" jsonschema:"Whether the group is active (default: true)"`
+	Instance string  `json:"instance,omitempty"`
+}
+
+// registerCreateGroup registers the tool for creating a group
+func (r *Registry) registerCreateGroup(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "create_group",
+		Description: "Create a Pi-hole group, e.g. to apply stricter filtering to a set of devices. Assign clients with assign_client_to_group and attach adlists or domain rules to the group.",
+		Annotations: writeAnnotations(false, true, false),
+	}, r.handleCreateGroup)
+}
+
+// handleCreateGroup handles requests for the create_group tool
+func (r *Registry) handleCreateGroup(ctx context.Context, request *mcp.CallToolRequest, args createGroupInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Validate name is provided
+	args.Name = strings.TrimSpace(args.Name)
+	if args.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		res, err := c.AddGroup(ctx, args.Name, client.GroupInput{Comment: args.Comment, Enabled: args.Enabled})
+		if err != nil {
+			return struct{}{}, err
+		}
+		if res.Processed != nil && len(res.Processed.Errors) > 0 {
+			return struct{}{}, fmt.Errorf("%s", res.Processed.Errors[0].Error)
+		}
+		return struct{}{}, nil
+	})
+	return r.changeResult(results, err, fmt.Sprintf("Created group %q", args.Name), fmt.Sprintf("Failed to create group %q", args.Name))
+}
+
+type deleteGroupInput struct {
+	Name     string `json:"name" jsonschema:"Name of the group to delete"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerDeleteGroup registers the tool for deleting a group
+func (r *Registry) registerDeleteGroup(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "delete_group",
+		Description: "Delete a Pi-hole group. Clients, adlists and domain rules assigned to it lose that assignment. The Default group cannot be deleted.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleDeleteGroup)
+}
+
+// handleDeleteGroup handles requests for the delete_group tool
+func (r *Registry) handleDeleteGroup(ctx context.Context, request *mcp.CallToolRequest, args deleteGroupInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Validate name is provided
+	if args.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		groups, err := c.GetGroups(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		ids, err := resolveGroupIDs(groups, []string{args.Name})
+		if err != nil {
+			return struct{}{}, err
+		}
+		if ids[0] == client.DefaultGroupID {
+			return struct{}{}, fmt.Errorf("the Default group cannot be deleted")
+		}
+		return struct{}{}, c.DeleteGroup(ctx, groupName(groups, ids[0]))
+	})
+	return r.changeResult(results, err, fmt.Sprintf("Deleted group %q", args.Name), fmt.Sprintf("Failed to delete group %q", args.Name))
+}
+
+type updateGroupInput struct {
+	Name     string  `json:"name" jsonschema:"Name (or ID) of the group to update"`
+	NewName  string  `json:"new_name,omitempty" jsonschema:"New name of the group"`
+	Comment  *string `json:"comment,omitempty" jsonschema:"New description of the group, empty to clear it"`
+	Enabled  *bool   `json:"enabled,omitempty" jsonschema:"Whether the group is active"`
+	Instance string  `json:"instance,omitempty"`
+}
+
+// registerUpdateGroup registers the tool for renaming, describing and enabling/disabling a group
+func (r *Registry) registerUpdateGroup(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "update_group",
+		Description: "Rename a Pi-hole group, change its comment, or enable or disable it. A disabled group's adlists and domain rules stop applying to its clients. Fields that are not given are left unchanged.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleUpdateGroup)
+}
+
+// handleUpdateGroup handles requests for the update_group tool
+func (r *Registry) handleUpdateGroup(ctx context.Context, request *mcp.CallToolRequest, args updateGroupInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Validate name and at least one change are provided
+	args.NewName = strings.TrimSpace(args.NewName)
+	if args.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+	if args.NewName == "" && args.Comment == nil && args.Enabled == nil {
+		return nil, nil, errors.New("new_name, comment or enabled is required")
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		groups, err := c.GetGroups(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		ids, err := resolveGroupIDs(groups, []string{args.Name})
+		if err != nil {
+			return struct{}{}, err
+		}
+		i := slices.IndexFunc(groups, func(g client.Group) bool { return g.Id == ids[0] })
+		group := groups[i]
+
+		// Pi-hole replaces the whole group, so unchanged fields are sent as they are
+		newName, input := group.Name, client.GroupInput{Comment: group.Comment, Enabled: &group.Enabled}
+		if args.NewName != "" {
+			newName = args.NewName
+		}
+		if args.Comment != nil {
+			input.Comment = args.Comment
+		}
+		if args.Enabled != nil {
+			input.Enabled = args.Enabled
+		}
+
+		res, err := c.UpdateGroup(ctx, group.Name, newName, input)
+		if err != nil {
+			return struct{}{}, err
+		}
+		if res.Processed != nil && len(res.Processed.Errors) > 0 {
+			return struct{}{}, fmt.Errorf("%s", res.Processed.Errors[0].Error)
+		}
+		return struct{}{}, nil
+	})
+	return r.changeResult(results, err, fmt.Sprintf("Updated group %q", args.Name), fmt.Sprintf("Failed to update group %q", args.Name))
+}
+
+type assignClientToGroupInput struct {
+	Client   string   `json:"client" jsonschema:"IP address, subnet (e.g. 192.168.1.0/24), MAC address or hostname of the client"`
+	Groups   []string `json:"groups" jsonschema:"Names (or IDs) of the groups to assign" minItems:"1"`
+	Replace  bool     `json:"replace,omitempty" jsonschema:"Replace the client's groups instead of adding to them (default: false)"`
+	Comment  *string  `json:"comment,omitempty" jsonschema:"Comment stored with the client, e.g. the device's owner"`
+	Instance string   `json:"instance,omitempty"`
+}
+
+// registerAssignClientToGroup registers the tool for assigning a client to groups
+func (r *Registry) registerAssignClientToGroup(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "assign_client_to_group",
+		Description: "Assign a client (identified by IP address, subnet in CIDR notation, MAC address or hostname) to one or more Pi-hole groups, so the group's adlists and domain rules apply to it. By default the groups are added to the client's current ones; clients not assigned yet start in the Default group.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleAssignClientToGroup)
+}
+
+// handleAssignClientToGroup handles requests for the assign_client_to_group tool
+func (r *Registry) handleAssignClientToGroup(ctx context.Context, request *mcp.CallToolRequest, args assignClientToGroupInput) (*mcp.CallToolResult, *clientAssignmentResponse, error) {
+	return r.updateClientGroups(ctx, args, false)
+}
+
+type removeClientFromGroupInput struct {
+	Client   string   `json:"client" jsonschema:"IP address, subnet, MAC address or hostname of the client, as listed by list_groups"`
+	Groups   []string `json:"groups" jsonschema:"Names (or IDs) of the groups to remove the client from" minItems:"1"`
+	Instance string   `json:"instance,omitempty"`
+}
+
+// registerRemoveClientFromGroup registers the tool for removing a client from groups
+func (r *Registry) registerRemoveClientFromGroup(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "remove_client_from_group",
+		Description: "Remove a client from one or more Pi-hole groups. A client left without any group is not filtered at all.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleRemoveClientFromGroup)
+}
+
+// handleRemoveClientFromGroup handles requests for the remove_client_from_group tool
+func (r *Registry) handleRemoveClientFromGroup(ctx context.Context, request *mcp.CallToolRequest, args removeClientFromGroupInput) (*mcp.CallToolResult, *clientAssignmentResponse, error) {
+	return r.updateClientGroups(ctx, assignClientToGroupInput{
+		Client:   args.Client,
+		Groups:   args.Groups,
+		Instance: args.Instance,
+	}, true)
+}
+
+// updateClientGroups implements assign_client_to_group and remove_client_from_group
+func (r *Registry) updateClientGroups(ctx context.Context, args assignClientToGroupInput, remove bool) (*mcp.CallToolResult, *clientAssignmentResponse, error) {
+	// Validate client and groups are provided
+	if args.Client == "" || len(args.Groups) == 0 {
+		return nil, nil, errors.New("client and groups are required")
+	}
+	clientID, err := client.NormalizeClientID(args.Client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Group IDs can differ between instances, so names are resolved per instance
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (clientAssignmentInfo, error) {
+		membership, err := c.GetGroupMembership(ctx)
+		if err != nil {
+			return clientAssignmentInfo{}, err
+		}
+		groups := make([]client.Group, 0, len(membership.Groups))
+		for _, g := range membership.Groups {
+			groups = append(groups, g)
+		}
+		ids, err := resolveGroupIDs(groups, args.Groups)
+		if err != nil {
+			return clientAssignmentInfo{}, err
+		}
+
+		var existing *client.ManagedClient
+		for i, mc := range membership.Clients {
+			if strings.EqualFold(mc.Client, clientID) {
+				existing = &membership.Clients[i]
+				break
+			}
+		}
+		if existing == nil && remove {
+			return clientAssignmentInfo{}, fmt.Errorf("client %q is not assigned to any group", clientID)
+		}
+
+		// Unassigned clients implicitly belong to the Default group
+		current := []int{client.DefaultGroupID}
+		if existing != nil {
+			current = existing.Groups
+		}
+
+		var updated []int
+		switch {
+		case remove:
+			for _, id := range current {
+				if !slices.Contains(ids, id) {
+					updated = append(updated, id)
+				}
+			}
+		case args.Replace:
+			updated = ids
+		default:
+			updated = append(slices.Clone(current), ids...)
+		}
+		slices.Sort(updated)
+		updated = slices.Compact(updated)
+		if updated == nil {
+			updated = []int{}
+		}
+
+		input := client.ManagedClientInput{Comment: args.Comment, Groups: updated}
+		var processed *client.ProcessedItems
+		if existing == nil {
+			res, err := c.AddManagedClient(ctx, clientID, input)
+			if err != nil {
+				return clientAssignmentInfo{}, err
+			}
+			processed = res.Processed
+		} else {
+			if input.Comment == nil {
+				input.Comment = existing.Comment
+			}
+			res, err := c.UpdateManagedClient(ctx, existing.Client, input)
+			if err != nil {
+				return clientAssignmentInfo{}, err
+			}
+			processed = res.Processed
+		}
+		if processed != nil && len(processed.Errors) > 0 {
+			return clientAssignmentInfo{}, fmt.Errorf("%s", processed.Errors[0].Error)
+		}
+
+		info := clientAssignmentInfo{Client: clientID, Groups: []string{}}
+		for _, id := range updated {
+			info.Groups = append(info.Groups, groupName(groups, id))
+		}
+		if len(updated) == 0 {
+			info.Warning = "client is no longer in any group and will not be filtered"
+		}
+		return info, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to update groups of client %q: %s", clientID, r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := clientAssignmentResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+	}
+	for _, res := range succeeded {
+		res.Value.Instance = res.Instance
+		response.Assignments = append(response.Assignments, res.Value)
+	}
+
+	return nil, &response, nil
+}
+
+// resolveGroupIDs maps group names (case-insensitive) or numeric IDs to group IDs
+func resolveGroupIDs(groups []client.Group, names []string) ([]int, error) {
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, g := range groups {
+			if strings.EqualFold(g.Name, name) || strconv.Itoa(g.Id) == name {
+				ids = append(ids, g.Id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown group %q", name)
+		}
+	}
+	return ids, nil
+}
+
+// groupName returns the name of the group with the given ID, or the ID itself if unknown
+func groupName(groups []client.Group, id int) string {
+	for _, g := range groups {
+		if g.Id == id {
+			return g.Name
+		}
+	}
+	return strconv.Itoa(id)
+}
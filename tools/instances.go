@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// allInstances is the instance argument value that fans a call out to every Pi-hole
+const allInstances = "all"
+
+// instanceResult holds the outcome of a call against a single Pi-hole instance
+type instanceResult[T any] struct {
+	Instance string
+	Value    T
+	Err      error
+}
+
+// instanceInput is the input of tools that only take the instance argument.
+// Its schema is filled in by inputSchema.
+type instanceInput struct {
+	Instance string `json:"instance,omitempty"`
+}
+
+// resolveInstances returns the instance names targeted by the instance argument
+func (r *Registry) resolveInstances(instance string) ([]string, error) {
+	switch instance {
+	case "":
+		return r.instances[:1], nil
+	case allInstances:
+		return r.instances, nil
+	}
+	if _, ok := r.clients[instance]; !ok {
+		return nil, fmt.Errorf("unknown Pi-hole instance %q (available: %s, %s)", instance, strings.Join(r.instances, ", "), allInstances)
+	}
+	return []string{instance}, nil
+}
+
+// singleInstance returns the client targeted by the instance argument for
+// operations that only make sense against one Pi-hole
+func (r *Registry) singleInstance(instance string) (string, *client.Client, error) {
+	if instance == allInstances {
+		return "", nil, fmt.Errorf("this operation targets a single Pi-hole instance, %q is not supported", allInstances)
+	}
+	names, err := r.resolveInstances(instance)
+	if err != nil {
+		return "", nil, err
+	}
+	return names[0], r.clients[names[0]], nil
+}
+
+// fanOut calls fn concurrently with the name and client of every instance
+// targeted by the instance argument and returns the per-instance results in
+// configured order
+func fanOut[T any](ctx context.Context, r *Registry, instance string, fn func(ctx context.Context, name string, c *client.Client) (T, error)) ([]instanceResult[T], error) {
+	names, err := r.resolveInstances(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]instanceResult[T], len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := fn(ctx, name, r.clients[name])
+			results[i] = instanceResult[T]{Instance: name, Value: value, Err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// splitResults separates successful results from failures. Failures are returned
+// as a map of instance name to error message, suitable for a tool response.
+func splitResults[T any](results []instanceResult[T]) (ok []instanceResult[T], errs map[string]string) {
+	for _, res := range results {
+		if res.Err != nil {
+			if errs == nil {
+				errs = make(map[string]string)
+			}
+			errs[res.Instance] = res.Err.Error()
+			continue
+		}
+		ok = append(ok, res)
+	}
+	return ok, errs
+}
+
+// changeResponse is the result of a tool that applies a change to one or more instances
+type changeResponse struct {
+	Message        string            `json:"message"`
+	Instances      []string          `json:"instances"`
+	InstanceErrors map[string]string `json:"instance_errors,omitempty"`
+}
+
+// changeResult builds the result of a tool that applies a change to one or more instances
+func (r *Registry) changeResult(results []instanceResult[struct{}], err error, success, failure string) (*mcp.CallToolResult, *changeResponse, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("%s: %s", failure, r.formatInstanceErrors(instanceErrors))
+	}
+
+	message := fmt.Sprintf("%s on %s", success, strings.Join(instanceNames(succeeded), ", "))
+	if len(instanceErrors) > 0 {
+		message += fmt.Sprintf(" (failed on %s)", r.formatInstanceErrors(instanceErrors))
+	}
+
+	return nil, &changeResponse{
+		Message:        message,
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+	}, nil
+}
+
+// formatInstanceErrors renders per-instance errors as a single message. Instance
+// names are left out when only one Pi-hole is configured.
+func (r *Registry) formatInstanceErrors(errs map[string]string) string {
+	if len(r.instances) == 1 {
+		for _, msg := range errs {
+			return msg
+		}
+	}
+
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %s", name, errs[name]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// instanceNames returns the names of the instances in results
+func instanceNames[T any](results []instanceResult[T]) []string {
+	names := make([]string, 0, len(results))
+	for _, res := range results {
+		names = append(names, res.Instance)
+	}
+	return names
+}
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type localDNSInstance struct {
+	Instance string                  `json:"instance"`
+	Records  []client.LocalDNSRecord `json:"records"`
+	CNAMEs   []client.LocalCNAME     `json:"cnames"`
+	// Unparsed entries are listed as Pi-hole stores them
+	UnparsedHosts  []string `json:"unparsed_hosts,omitempty"`
+	UnparsedCNAMEs []string `json:"unparsed_cnames,omitempty"`
+}
+
+type localDNSResponse struct {
+	Instances      []string           `json:"instances"`
+	InstanceErrors map[string]string  `json:"instance_errors,omitempty"`
+	LocalDNS       []localDNSInstance `json:"local_dns"`
+}
+
+// registerListLocalDNS registers the tool for listing local DNS records and CNAMEs
+func (r *Registry) registerListLocalDNS(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "list_local_dns",
+		Description: "List the local DNS records (hostname to IP) and CNAME records Pi-hole answers for itself, e.g. homelab hostnames",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleListLocalDNS)
+}
+
+// handleListLocalDNS handles requests for the list_local_dns tool
+func (r *Registry) handleListLocalDNS(ctx context.Context, request *mcp.CallToolRequest, args instanceInput) (*mcp.CallToolResult, *localDNSResponse, error) {
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*client.LocalDNS, error) {
+		return c.GetLocalDNS(ctx)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to list local DNS: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := localDNSResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+	}
+	for _, res := range succeeded {
+		entry := localDNSInstance{
+			Instance: res.Instance,
+			Records:  res.Value.Records,
+			CNAMEs:   res.Value.CNAMEs,
+
+			UnparsedHosts:  res.Value.UnparsedHosts,
+			UnparsedCNAMEs: res.Value.UnparsedCNAMEs,
+		}
+		if entry.Records == nil {
+			entry.Records = []client.LocalDNSRecord{}
+		}
+		if entry.CNAMEs == nil {
+			entry.CNAMEs = []client.LocalCNAME{}
+		}
+		response.LocalDNS = append(response.LocalDNS, entry)
+	}
+
+	return nil, &response, nil
+}
+
+type addLocalDNSRecordInput struct {
+	Hostname string `json:"hostname" jsonschema:"Hostname to resolve (e.g. nas.lan)"`
+	IP       string `json:"ip" jsonschema:"IPv4 or IPv6 address the hostname resolves to"`
+	Replace  bool   `json:"replace,omitempty" jsonschema:"Replace an existing address of the same IP version for the hostname (default: false)"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerAddLocalDNSRecord registers the tool for adding a local DNS record
+func (r *Registry) registerAddLocalDNSRecord(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "add_local_dns_record",
+		Description: "Add a local DNS record so Pi-hole resolves a hostname to an IP address. Fails if the hostname is a CNAME or already resolves to another address of the same IP version, unless replace is set.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleAddLocalDNSRecord)
+}
+
+// handleAddLocalDNSRecord handles requests for the add_local_dns_record tool
+func (r *Registry) handleAddLocalDNSRecord(ctx context.Context, request *mcp.CallToolRequest, args addLocalDNSRecordInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Validate hostname and IP
+	hostname, err := client.NormalizeHostname(args.Hostname)
+	if err != nil {
+		return nil, nil, err
+	}
+	ip := net.ParseIP(args.IP)
+	if ip == nil {
+		return nil, nil, fmt.Errorf("invalid IP address %q", args.IP)
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		local, err := c.GetLocalDNS(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		var edits []client.LocalDNSEdit
+		if args.Replace {
+			for _, existing := range local.HostIPs(hostname) {
+				if parsed := net.ParseIP(existing); parsed != nil && (parsed.To4() != nil) == (ip.To4() != nil) {
+					edits = append(edits, local.RemoveHostname(hostname, existing)...)
+				}
+			}
+		}
+		if err := local.CheckRecord(ip.String(), hostname); err != nil {
+			return struct{}{}, err
+		}
+		// Add the new record first so the hostname keeps resolving if removing the old one fails
+		if err := c.AddLocalDNSRecord(ctx, client.LocalDNSRecord{IP: ip.String(), Hostnames: []string{hostname}}); err != nil {
+			return struct{}{}, err
+		}
+		for _, edit := range edits {
+			if err := c.EditLocalDNS(ctx, edit); err != nil {
+				return struct{}{}, err
+			}
+		}
+		return struct{}{}, nil
+	})
+	return r.changeResult(results, err,
+		fmt.Sprintf("Added local DNS record %s -> %s", hostname, ip),
+		fmt.Sprintf("Failed to add local DNS record %s", hostname),
+	)
+}
+
+type addLocalCNAMEInput struct {
+	Domain   string `json:"domain" jsonschema:"Domain the CNAME is created for (e.g. photos.lan)"`
+	Target   string `json:"target" jsonschema:"Hostname the domain points to (e.g. nas.lan)"`
+	TTL      int    `json:"ttl,omitempty" jsonschema:"TTL of the answer in seconds (default: Pi-hole's local TTL)" minimum:"0"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerAddLocalCNAME registers the tool for adding a local CNAME record
+func (r *Registry) registerAddLocalCNAME(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "add_local_cname",
+		Description: "Add a local CNAME record so Pi-hole answers a domain with another hostname. Fails if the domain already has a local record or CNAME, or if the CNAME would create a loop.",
+		Annotations: writeAnnotations(false, true, false),
+	}, r.handleAddLocalCNAME)
+}
+
+// handleAddLocalCNAME handles requests for the add_local_cname tool
+func (r *Registry) handleAddLocalCNAME(ctx context.Context, request *mcp.CallToolRequest, args addLocalCNAMEInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Validate domain and target
+	domain, err := client.NormalizeHostname(args.Domain)
+	if err == nil {
+		args.Target, err = client.NormalizeHostname(args.Target)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		local, err := c.GetLocalDNS(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		if err := local.CheckCNAME(domain, args.Target); err != nil {
+			return struct{}{}, err
+		}
+		return struct{}{}, c.AddLocalCNAME(ctx, client.LocalCNAME{Domains: []string{domain}, Target: args.Target, TTL: args.TTL})
+	})
+	return r.changeResult(results, err,
+		fmt.Sprintf("Added local CNAME %s -> %s", domain, args.Target),
+		fmt.Sprintf("Failed to add local CNAME %s", domain),
+	)
+}
+
+type removeLocalDNSRecordInput struct {
+	Hostname string `json:"hostname" jsonschema:"Hostname or CNAME domain to remove"`
+	IP       string `json:"ip,omitempty" jsonschema:"Only remove the record resolving to this address (CNAMEs are left untouched)"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// registerRemoveLocalDNSRecord registers the tool for removing local DNS records and CNAMEs
+func (r *Registry) registerRemoveLocalDNSRecord(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "remove_local_dns_record",
+		Description: "Remove a hostname from Pi-hole's local DNS records and CNAMEs. Pass ip to only remove the record for that address.",
+		Annotations: writeAnnotations(true, true, false),
+	}, r.handleRemoveLocalDNSRecord)
+}
+
+// handleRemoveLocalDNSRecord handles requests for the remove_local_dns_record tool
+func (r *Registry) handleRemoveLocalDNSRecord(ctx context.Context, request *mcp.CallToolRequest, args removeLocalDNSRecordInput) (*mcp.CallToolResult, *changeResponse, error) {
+	// Validate hostname and optional IP
+	hostname, err := client.NormalizeHostname(args.Hostname)
+	if err == nil && args.IP != "" && net.ParseIP(args.IP) == nil {
+		err = fmt.Errorf("invalid IP address %q", args.IP)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (struct{}, error) {
+		local, err := c.GetLocalDNS(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		edits := local.RemoveHostname(hostname, args.IP)
+		if len(edits) == 0 {
+			return struct{}{}, fmt.Errorf("no local DNS record or CNAME for %s", hostname)
+		}
+		for _, edit := range edits {
+			if err := c.EditLocalDNS(ctx, edit); err != nil {
+				return struct{}{}, err
+			}
+		}
+		return struct{}{}, nil
+	})
+	return r.changeResult(results, err,
+		fmt.Sprintf("Removed local DNS entries for %s", hostname),
+		fmt.Sprintf("Failed to remove local DNS entries for %s", hostname),
+	)
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type networkSummaryInfo struct {
+	Instance          string  `json:"instance,omitempty"`
+	TotalQueries      int     `json:"total_queries"`
+	BlockedQueries    int     `json:"blocked_queries"`
+	CachedQueries     int     `json:"cached_queries"`
+	ForwardedQueries  int     `json:"forwarded_queries"`
+	PercentBlocked    float64 `json:"percent_blocked"`
+	UniqueDomains     int     `json:"unique_domains,omitempty"`
+	ActiveClients     int     `json:"active_clients,omitempty"`
+	GravityDomains    int     `json:"gravity_domains,omitempty"`
+	GravityLastUpdate string  `json:"gravity_last_update,omitempty"`
+}
+
+type networkSummaryResponse struct {
+	Instances      []string             `json:"instances"`
+	InstanceErrors map[string]string    `json:"instance_errors,omitempty"`
+	Summaries      []networkSummaryInfo `json:"summaries"`
+	Combined       *networkSummaryInfo  `json:"combined,omitempty"`
+}
+
+// registerNetworkSummary registers the tool for getting an overview of network activity
+func (r *Registry) registerNetworkSummary(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "get_network_summary",
+		Description: "Get an overview of DNS activity over the last 24 hours: total, blocked, cached and forwarded queries, percentage blocked, unique domains, active clients, and the size and age of gravity. Answers \"how's the network today?\"",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleNetworkSummary)
+}
+
+// handleNetworkSummary handles requests for the get_network_summary tool
+func (r *Registry) handleNetworkSummary(ctx context.Context, request *mcp.CallToolRequest, args instanceInput) (*mcp.CallToolResult, *networkSummaryResponse, error) {
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*client.Summary, error) {
+		return c.GetSummary(ctx)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to get network summary: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	response := networkSummaryResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+	}
+	// Query counts add up across instances; unique domains, clients and gravity
+	// overlap, so they are only reported per instance
+	var combined networkSummaryInfo
+	for _, res := range succeeded {
+		info := newNetworkSummaryInfo(res.Instance, res.Value)
+		response.Summaries = append(response.Summaries, info)
+
+		combined.TotalQueries += info.TotalQueries
+		combined.BlockedQueries += info.BlockedQueries
+		combined.CachedQueries += info.CachedQueries
+		combined.ForwardedQueries += info.ForwardedQueries
+	}
+	if len(succeeded) > 1 {
+		if combined.TotalQueries > 0 {
+			combined.PercentBlocked = roundPercent(float64(combined.BlockedQueries) / float64(combined.TotalQueries) * 100)
+		}
+		response.Combined = &combined
+	}
+
+	return nil, &response, nil
+}
+
+// newNetworkSummaryInfo converts the summary of an instance. The time the
+// request took and the query frequency are left out, so the info only changes
+// with the counts.
+func newNetworkSummaryInfo(instance string, summary *client.Summary) networkSummaryInfo {
+	info := networkSummaryInfo{
+		Instance:         instance,
+		TotalQueries:     summary.Queries.Total,
+		BlockedQueries:   summary.Queries.Blocked,
+		CachedQueries:    summary.Queries.Cached,
+		ForwardedQueries: summary.Queries.Forwarded,
+		PercentBlocked:   roundPercent(summary.Queries.PercentBlocked),
+		UniqueDomains:    summary.Queries.UniqueDomains,
+		ActiveClients:    summary.Clients.Active,
+		GravityDomains:   summary.Gravity.DomainsBeingBlocked,
+	}
+	if summary.Gravity.LastUpdate.Unix() > 0 {
+		info.GravityLastUpdate = summary.Gravity.LastUpdate.Format(time.RFC3339)
+	}
+	return info
+}
+
+// roundPercent rounds a percentage to two decimal places
+func roundPercent(p float64) float64 {
+	return math.Round(p*100) / 100
+}
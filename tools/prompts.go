@@ -44,6 +44,12 @@ Please follow these steps:
      * NS records (Name servers) - Identify DNS providers
      * MX records (Mail servers) - Identify email infrastructure
      * TXT records - Look for SPF, DKIM, DMARC, domain verification, and other configurations
+     * CNAME chain - Identify CDNs and third-party services the name is an alias of
+     * SOA record - Primary name server, admin contact and zone serial
+     * CAA records - Which certificate authorities may issue certificates
+     * HTTPS/SVCB records - HTTP/3 support (ALPN) and Encrypted ClientHello
+     * DS/DNSKEY records - Whether the zone is signed with DNSSEC
+     * PTR records - Reverse DNS names of the addresses, often naming the hosting provider
 
 2. **WHOIS Information**
    - Use the 'get_domain_whois' tool to gather registration details
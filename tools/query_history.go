@@ -0,0 +1,309 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// historyBuckets maps the bucket argument to the bucket size. Pi-hole itself
+// records history in 10 minute intervals.
+var historyBuckets = map[string]time.Duration{
+	"10m": 10 * time.Minute,
+	"1h":  time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// historySample is one interval of query history as returned by Pi-hole
+type historySample struct {
+	Time      time.Time
+	Total     int
+	Blocked   int
+	Cached    int
+	Forwarded int
+	Clients   map[string]int
+}
+
+type historyClientInfo struct {
+	IP   string `json:"ip"`
+	Name string `json:"name,omitempty"`
+}
+
+type historySeriesPoint struct {
+	Time      string         `json:"time"`
+	Total     int            `json:"total"`
+	Blocked   *int           `json:"blocked,omitempty"`
+	Cached    *int           `json:"cached,omitempty"`
+	Forwarded *int           `json:"forwarded,omitempty"`
+	Clients   map[string]int `json:"clients,omitempty"`
+}
+
+type historyTotals struct {
+	Total     int  `json:"total"`
+	Blocked   *int `json:"blocked,omitempty"`
+	Cached    *int `json:"cached,omitempty"`
+	Forwarded *int `json:"forwarded,omitempty"`
+}
+
+type queryHistoryResponse struct {
+	Instances      []string             `json:"instances"`
+	InstanceErrors map[string]string    `json:"instance_errors,omitempty"`
+	From           string               `json:"from"`
+	Until          string               `json:"until"`
+	Bucket         string               `json:"bucket"`
+	Clients        []historyClientInfo  `json:"clients,omitempty"`
+	Totals         historyTotals        `json:"totals"`
+	Peak           *historySeriesPoint  `json:"peak,omitempty"`
+	Series         []historySeriesPoint `json:"series"`
+	Note           string               `json:"note,omitempty"`
+}
+
+type instanceHistory struct {
+	Samples []historySample
+	Clients []historyClientInfo
+}
+
+type queryHistoryInput struct {
+	Hours    float64  `json:"hours,omitempty" jsonschema:"Length of the window in hours, ending at until (default: 24, max: 744)" default:"24" minimum:"1" maximum:"744"`
+	Until    string   `json:"until,omitempty" jsonschema:"End of the window as an RFC 3339 timestamp (default: now)"`
+	Bucket   string   `json:"bucket,omitempty" jsonschema:"Size of each point in the series (default: 10m for windows up to 6 hours, 1h up to 7 days, 1d beyond)" enum:"10m,1h,1d"`
+	Clients  []string `json:"clients,omitempty" jsonschema:"Only count queries from these clients (IP addresses or hostnames). Blocked/cached/forwarded counts are not available per client."`
+	Instance string   `json:"instance,omitempty"`
+}
+
+// registerQueryHistory registers the tool for getting the query history as a time series
+func (r *Registry) registerQueryHistory(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "get_query_history",
+		Description: "Get the number of DNS queries over time (total, blocked, cached, forwarded) as a time series, optionally for specific clients only. Use it to find when traffic spiked, e.g. \"when did traffic spike last night?\"",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleQueryHistory)
+}
+
+// handleQueryHistory handles requests for the get_query_history tool
+func (r *Registry) handleQueryHistory(ctx context.Context, request *mcp.CallToolRequest, args queryHistoryInput) (*mcp.CallToolResult, *queryHistoryResponse, error) {
+	// Calculate time range
+	now := time.Now()
+	until := now
+	if args.Until != "" {
+		parsed, err := time.Parse(time.RFC3339, args.Until)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid until %q: expected an RFC 3339 timestamp", args.Until)
+		}
+		if parsed.Before(now) {
+			until = parsed
+		}
+	}
+	from := until.Add(-time.Duration(args.Hours * float64(time.Hour)))
+
+	// Pick a bucket size that keeps the series readable
+	if args.Bucket == "" {
+		switch window := until.Sub(from); {
+		case window <= 6*time.Hour:
+			args.Bucket = "10m"
+		case window <= 7*24*time.Hour:
+			args.Bucket = "1h"
+		default:
+			args.Bucket = "1d"
+		}
+	}
+	bucketSize, ok := historyBuckets[args.Bucket]
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid bucket %q: expected 10m, 1h or 1d", args.Bucket)
+	}
+
+	// Anything older than Pi-hole's in-memory window comes from the database
+	fromDatabase := from.Before(now.Add(-client.HistoryWindow))
+
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*instanceHistory, error) {
+		if len(args.Clients) > 0 {
+			return clientQueryHistory(ctx, c, from, until, fromDatabase, args.Clients)
+		}
+		return queryHistory(ctx, c, from, until, fromDatabase)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to get query history: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	// Re-bucket the samples of every instance into one series
+	buckets := make(map[int64]*historySample)
+	seenClients := make(map[string]bool)
+	var clients []historyClientInfo
+	for _, res := range succeeded {
+		for _, sample := range res.Value.Samples {
+			start := historyBucketStart(sample.Time, bucketSize)
+			bucket, ok := buckets[start.Unix()]
+			if !ok {
+				bucket = &historySample{Time: start}
+				buckets[start.Unix()] = bucket
+			}
+			bucket.Total += sample.Total
+			bucket.Blocked += sample.Blocked
+			bucket.Cached += sample.Cached
+			bucket.Forwarded += sample.Forwarded
+			for ip, count := range sample.Clients {
+				if bucket.Clients == nil {
+					bucket.Clients = make(map[string]int)
+				}
+				bucket.Clients[ip] += count
+			}
+		}
+		for _, info := range res.Value.Clients {
+			if !seenClients[info.IP] {
+				seenClients[info.IP] = true
+				clients = append(clients, info)
+			}
+		}
+	}
+
+	starts := make([]int64, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	perClient := len(args.Clients) > 0
+	response := queryHistoryResponse{
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		From:           from.Format(time.RFC3339),
+		Until:          until.Format(time.RFC3339),
+		Bucket:         args.Bucket,
+		Clients:        clients,
+		Series:         make([]historySeriesPoint, 0, len(starts)),
+	}
+	var totals historySample
+	for _, start := range starts {
+		bucket := buckets[start]
+		point := historySeriesPoint{
+			Time:  bucket.Time.Format(time.RFC3339),
+			Total: bucket.Total,
+		}
+		if perClient {
+			point.Clients = bucket.Clients
+		} else {
+			point.Blocked = &bucket.Blocked
+			point.Cached = &bucket.Cached
+			point.Forwarded = &bucket.Forwarded
+		}
+		response.Series = append(response.Series, point)
+
+		totals.Total += bucket.Total
+		totals.Blocked += bucket.Blocked
+		totals.Cached += bucket.Cached
+		totals.Forwarded += bucket.Forwarded
+		if response.Peak == nil || point.Total > response.Peak.Total {
+			peak := point
+			response.Peak = &peak
+		}
+	}
+	response.Totals.Total = totals.Total
+	if perClient {
+		response.Note = "Blocked, cached and forwarded counts are not available per client"
+	} else {
+		response.Totals.Blocked = &totals.Blocked
+		response.Totals.Cached = &totals.Cached
+		response.Totals.Forwarded = &totals.Forwarded
+	}
+
+	return nil, &response, nil
+}
+
+// queryHistory returns the overall query history of one Pi-hole between from and until
+func queryHistory(ctx context.Context, c *client.Client, from, until time.Time, fromDatabase bool) (*instanceHistory, error) {
+	var points []client.HistoryPoint
+	var err error
+	if fromDatabase {
+		points, err = c.GetHistoryRange(ctx, from, until)
+	} else {
+		points, err = c.GetHistory(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	history := &instanceHistory{}
+	for _, p := range points {
+		if t := p.Time(); !t.Before(from) && t.Before(until) {
+			history.Samples = append(history.Samples, historySample{
+				Time:      t,
+				Total:     p.Total,
+				Blocked:   p.Blocked,
+				Cached:    p.Cached,
+				Forwarded: p.Forwarded,
+			})
+		}
+	}
+	return history, nil
+}
+
+// clientQueryHistory returns the query history of the given clients on one
+// Pi-hole between from and until. Clients are matched by IP or hostname.
+func clientQueryHistory(ctx context.Context, c *client.Client, from, until time.Time, fromDatabase bool, wanted []string) (*instanceHistory, error) {
+	var res *client.ClientHistory
+	var err error
+	if fromDatabase {
+		res, err = c.GetClientHistoryRange(ctx, from, until)
+	} else {
+		res, err = c.GetClientHistory(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	history := &instanceHistory{}
+	matched := make(map[string]bool)
+	for ip, info := range res.Clients {
+		name := ""
+		if info.Name != nil {
+			name = *info.Name
+		}
+		for _, w := range wanted {
+			if ip == w || (name != "" && strings.EqualFold(name, w)) {
+				matched[ip] = true
+				history.Clients = append(history.Clients, historyClientInfo{IP: ip, Name: name})
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no query history for clients %s", strings.Join(wanted, ", "))
+	}
+	sort.Slice(history.Clients, func(i, j int) bool { return history.Clients[i].IP < history.Clients[j].IP })
+
+	for _, p := range res.History {
+		t := p.Time()
+		if t.Before(from) || !t.Before(until) {
+			continue
+		}
+		sample := historySample{Time: t, Clients: make(map[string]int)}
+		for ip, count := range p.Data {
+			if matched[ip] {
+				sample.Total += count
+				sample.Clients[ip] = count
+			}
+		}
+		history.Samples = append(history.Samples, sample)
+	}
+	return history, nil
+}
+
+// historyBucketStart returns the start of the bucket t falls into. Daily
+// buckets start at local midnight.
+func historyBucketStart(t time.Time, size time.Duration) time.Time {
+	if size >= 24*time.Hour {
+		year, month, day := t.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+	return t.Truncate(size)
+}
@@ -0,0 +1,342 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// resourceScheme is the URI scheme of the Pi-hole resources
+const resourceScheme = "pihole"
+
+// resourceClientQueries is the number of recent queries included in a client resource
+const resourceClientQueries = 100
+
+// instanceResources holds a resource's content per instance
+type instanceResources[T any] struct {
+	Instances      map[string]T      `json:"instances"`
+	InstanceErrors map[string]string `json:"instance_errors,omitempty"`
+}
+
+type clientResource struct {
+	Ip               string   `json:"ip"`
+	Name             string   `json:"name"`
+	DnsRequestsCount int      `json:"dns_requests_count"`
+	MacAddress       []string `json:"mac_address"`
+	MacVendor        string   `json:"mac_vendor"`
+	LastQuery        string   `json:"last_query,omitempty"`
+	Groups           []string `json:"groups,omitempty"`
+}
+
+type clientQueriesResource struct {
+	Categories map[client.QueryCategory]int `json:"categories"`
+	Queries    []queryLogInfo               `json:"recent_queries"`
+}
+
+type listsResource struct {
+	Adlists     []client.List       `json:"adlists"`
+	DomainRules []client.DomainRule `json:"domain_rules"`
+}
+
+// registerResources registers the Pi-hole state resources and resource templates
+func (r *Registry) registerResources(server *mcp.Server) {
+	server.AddResource(&mcp.Resource{
+		URI:         resourceScheme + "://summary",
+		Name:        "summary",
+		Title:       "Network summary",
+		Description: "Query counts, percentage blocked, active clients and gravity size over the last 24 hours, per Pi-hole instance",
+		MIMEType:    "application/json",
+	}, r.handleResource)
+	server.AddResource(&mcp.Resource{
+		URI:         resourceScheme + "://clients",
+		Name:        "clients",
+		Title:       "Active clients",
+		Description: "The most active clients with their MAC address, vendor, last query and groups, per Pi-hole instance",
+		MIMEType:    "application/json",
+	}, r.handleResource)
+	server.AddResource(&mcp.Resource{
+		URI:         resourceScheme + "://lists",
+		Name:        "lists",
+		Title:       "Adlists and domain rules",
+		Description: "The subscribed adlists and the exact/regex allow and deny rules, per Pi-hole instance",
+		MIMEType:    "application/json",
+	}, r.handleResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: resourceScheme + "://client/{ip}",
+		Name:        "client",
+		Title:       "Client activity",
+		Description: "The most recent queries of a client and how they were answered, per Pi-hole instance",
+		MIMEType:    "application/json",
+	}, r.handleResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: resourceScheme + "://domain/{name}",
+		Name:        "domain",
+		Title:       "Domain explanation",
+		Description: "The rules and adlists matching a domain, whether it is blocked, and its recent queries, per Pi-hole instance",
+		MIMEType:    "application/json",
+	}, r.handleResource)
+}
+
+// handleResource handles reads of every Pi-hole resource
+func (r *Registry) handleResource(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := request.Params.URI
+	start := time.Now()
+	content, err := r.readResource(ctx, uri)
+	if err != nil {
+		r.logger.Error("Resource read failed", "uri", uri, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	r.logger.Info("Resource read", "uri", uri, "duration", time.Since(start))
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{
+				URI:      uri,
+				MIMEType: "application/json",
+				Text:     string(content),
+			},
+		},
+	}, nil
+}
+
+// readResource returns the JSON content of the resource at uri. Reads are
+// counted as in flight, like tool calls, so Drain waits for them.
+func (r *Registry) readResource(ctx context.Context, uri string) ([]byte, error) {
+	r.inflight.Add(1)
+	defer r.inflight.Add(-1)
+
+	kind, param, err := parseResourceURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var content any
+	switch kind {
+	case "summary":
+		// Without the timings that change on every read, so polls only see real changes
+		content, err = readInstances(ctx, r, func(ctx context.Context, _ string, c *client.Client) (networkSummaryInfo, error) {
+			summary, err := c.GetSummary(ctx)
+			if err != nil {
+				return networkSummaryInfo{}, err
+			}
+			return newNetworkSummaryInfo("", summary), nil
+		})
+	case "clients":
+		content, err = readInstances(ctx, r, func(ctx context.Context, _ string, c *client.Client) ([]clientResource, error) {
+			usages, err := r.topActiveClients(ctx, c, 50)
+			if err != nil {
+				return nil, err
+			}
+			clients := make([]clientResource, 0, len(usages))
+			for _, usage := range usages {
+				res := clientResource{
+					Ip:               usage.info.Ip,
+					Name:             usage.info.Name,
+					DnsRequestsCount: usage.info.DnsRequestsCount,
+					MacAddress:       usage.info.MacAddress,
+					MacVendor:        usage.info.MacVendor,
+					Groups:           usage.info.Groups,
+				}
+				if !usage.lastQuery.IsZero() {
+					res.LastQuery = usage.lastQuery.Format(time.RFC3339)
+				}
+				clients = append(clients, res)
+			}
+			return clients, nil
+		})
+	case "client":
+		content, err = readInstances(ctx, r, func(ctx context.Context, _ string, c *client.Client) (*clientQueriesResource, error) {
+			queries, err := c.SearchQueries(ctx, client.QueryFilter{Client: param, Length: resourceClientQueries})
+			if err != nil {
+				return nil, err
+			}
+			res := &clientQueriesResource{
+				Categories: make(map[client.QueryCategory]int),
+				Queries:    make([]queryLogInfo, 0, len(queries.Queries)),
+			}
+			for _, q := range queries.Queries {
+				res.Categories[client.ClassifyStatus(q.Status)]++
+				res.Queries = append(res.Queries, newQueryLogInfo(q))
+			}
+			return res, nil
+		})
+	case "lists":
+		content, err = readInstances(ctx, r, func(ctx context.Context, _ string, c *client.Client) (*listsResource, error) {
+			adlists, err := c.GetLists(ctx, "")
+			if err != nil {
+				return nil, err
+			}
+			rules, err := c.GetDomainRules(ctx, "", "")
+			if err != nil {
+				return nil, err
+			}
+			return &listsResource{Adlists: adlists, DomainRules: rules}, nil
+		})
+	case "domain":
+		content, err = readInstances(ctx, r, func(ctx context.Context, _ string, c *client.Client) (*domainExplanation, error) {
+			return explainDomain(ctx, c, param, 10)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(content, "", "  ")
+}
+
+// parseResourceURI splits a Pi-hole resource URI into its kind and, for
+// templated resources, the validated parameter
+func parseResourceURI(uri string) (kind, param string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != resourceScheme {
+		return "", "", mcp.ResourceNotFoundError(uri)
+	}
+	param = strings.Trim(u.Path, "/")
+
+	switch u.Host {
+	case "summary", "clients", "lists":
+		if param != "" {
+			return "", "", mcp.ResourceNotFoundError(uri)
+		}
+	case "client":
+		ip := net.ParseIP(param)
+		if ip == nil {
+			return "", "", fmt.Errorf("invalid client IP address %q in %s", param, uri)
+		}
+		param = ip.String()
+	case "domain":
+		if param, err = client.NormalizeHostname(param); err != nil {
+			return "", "", fmt.Errorf("invalid domain in %s: %w", uri, err)
+		}
+	default:
+		return "", "", mcp.ResourceNotFoundError(uri)
+	}
+	return u.Host, param, nil
+}
+
+// readInstances reads a resource from every instance. It only fails if no
+// instance could be read.
+func readInstances[T any](ctx context.Context, r *Registry, fn func(ctx context.Context, name string, c *client.Client) (T, error)) (*instanceResources[T], error) {
+	results, err := fanOut(ctx, r, allInstances, fn)
+	if err != nil {
+		return nil, err
+	}
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, fmt.Errorf("%s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	content := &instanceResources[T]{
+		Instances:      make(map[string]T, len(succeeded)),
+		InstanceErrors: instanceErrors,
+	}
+	for _, res := range succeeded {
+		content.Instances[res.Instance] = res.Value
+	}
+	return content, nil
+}
+
+// Subscribe starts watching a resource for changes on behalf of the session.
+// It is meant to be used as the server's SubscribeHandler.
+func (r *Registry) Subscribe(ctx context.Context, request *mcp.SubscribeRequest) error {
+	if _, _, err := parseResourceURI(request.Params.URI); err != nil {
+		return err
+	}
+
+	r.subscriptionsMu.Lock()
+	defer r.subscriptionsMu.Unlock()
+	uris, ok := r.subscriptions[request.Session]
+	if !ok {
+		uris = make(map[string]bool)
+		r.subscriptions[request.Session] = uris
+		// Sessions that disconnect without unsubscribing stop watching too
+		go r.forgetSession(request.Session)
+	}
+	uris[request.Params.URI] = true
+	return nil
+}
+
+// Unsubscribe stops watching a resource on behalf of the session. It is meant
+// to be used as the server's UnsubscribeHandler.
+func (r *Registry) Unsubscribe(ctx context.Context, request *mcp.UnsubscribeRequest) error {
+	r.subscriptionsMu.Lock()
+	defer r.subscriptionsMu.Unlock()
+	if uris, ok := r.subscriptions[request.Session]; ok {
+		delete(uris, request.Params.URI)
+	}
+	return nil
+}
+
+// forgetSession drops the subscriptions of a session once it has closed
+func (r *Registry) forgetSession(session *mcp.ServerSession) {
+	if session != nil {
+		session.Wait()
+	}
+
+	r.subscriptionsMu.Lock()
+	defer r.subscriptionsMu.Unlock()
+	delete(r.subscriptions, session)
+}
+
+// watchedURIs returns the resources any session is subscribed to, and forgets
+// the hashes of the resources no session watches anymore
+func (r *Registry) watchedURIs() []string {
+	r.subscriptionsMu.Lock()
+	defer r.subscriptionsMu.Unlock()
+
+	watched := make(map[string]bool)
+	for _, uris := range r.subscriptions {
+		for uri := range uris {
+			watched[uri] = true
+		}
+	}
+	for uri := range r.resourceHashes {
+		if !watched[uri] {
+			delete(r.resourceHashes, uri)
+		}
+	}
+	return slices.Sorted(maps.Keys(watched))
+}
+
+// WatchResources polls the subscribed resources every interval and notifies
+// the subscribers of the ones whose content changed, until ctx is done
+func (r *Registry) WatchResources(ctx context.Context, server *mcp.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, uri := range r.watchedURIs() {
+			content, err := r.readResource(ctx, uri)
+			if err != nil {
+				r.logger.Warn("Failed to poll resource", "uri", uri, "error", err)
+				continue
+			}
+			hash := sha256.Sum256(content)
+
+			r.subscriptionsMu.Lock()
+			previous, seen := r.resourceHashes[uri]
+			r.resourceHashes[uri] = hash
+			r.subscriptionsMu.Unlock()
+
+			// The first poll only records a baseline
+			if seen && previous != hash {
+				server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri})
+			}
+		}
+	}
+}
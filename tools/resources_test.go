@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// newTestClient serves mux as a Pi-hole API that accepts any login and returns
+// a client logged in to it
+func newTestClient(t *testing.T, mux *http.ServeMux) *client.Client {
+	t.Helper()
+	mux.HandleFunc("POST /api/auth", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"session":{"valid":true,"sid":"sid","validity":1800}}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, err := client.NewClient(context.Background(), srv.URL+"/api", client.Credentials{Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// newSummaryPihole serves a Pi-hole whose summary reports total queries and a
+// different took and frequency on every request. It counts the summary requests.
+func newSummaryPihole(t *testing.T, total *atomic.Int64, requests *atomic.Int64) *client.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/stats/summary", func(w http.ResponseWriter, req *http.Request) {
+		n := requests.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{
+			"queries": map[string]any{"total": total.Load(), "frequency": float64(n) / 10},
+			"took":    float64(n) / 1000,
+		})
+	})
+	return newTestClient(t, mux)
+}
+
+// connect serves r's resources and connects a client session that counts the
+// resource update notifications it receives
+func connect(t *testing.T, r *Registry, updates *atomic.Int64) (*mcp.Server, *mcp.ClientSession) {
+	t.Helper()
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, &mcp.ServerOptions{
+		SubscribeHandler:   r.Subscribe,
+		UnsubscribeHandler: r.Unsubscribe,
+	})
+	r.registerResources(server)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := mcp.NewClient(&mcp.Implementation{Name: "test"}, &mcp.ClientOptions{
+		ResourceUpdatedHandler: func(context.Context, *mcp.ResourceUpdatedNotificationRequest) {
+			updates.Add(1)
+		},
+	})
+	session, err := mc.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, session
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return cond()
+}
+
+func TestWatchResourcesSummary(t *testing.T) {
+	var total, requests, updates atomic.Int64
+	total.Store(100)
+	c := newSummaryPihole(t, &total, &requests)
+	r := NewRegistry([]Instance{{Name: "primary", Client: c}}, nil, nil, false, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	server, session := connect(t, r, &updates)
+	defer session.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: "pihole://summary"}); err != nil {
+		t.Fatal(err)
+	}
+	go r.WatchResources(ctx, server, 10*time.Millisecond)
+
+	// Only the timings change between these polls
+	if !waitFor(t, func() bool { return requests.Load() >= 3 }) {
+		t.Fatal("the summary was not polled")
+	}
+	if n := updates.Load(); n != 0 {
+		t.Fatalf("got %d notifications for an unchanged summary, want none", n)
+	}
+
+	total.Store(101)
+	if !waitFor(t, func() bool { return updates.Load() > 0 }) {
+		t.Error("no notification after the query count changed")
+	}
+}
+
+func TestSubscriptionsClosedSession(t *testing.T) {
+	var total, requests, updates atomic.Int64
+	c := newSummaryPihole(t, &total, &requests)
+	r := NewRegistry([]Instance{{Name: "primary", Client: c}}, nil, nil, false, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	_, session := connect(t, r, &updates)
+
+	if err := session.Subscribe(context.Background(), &mcp.SubscribeParams{URI: "pihole://summary"}); err != nil {
+		t.Fatal(err)
+	}
+	if uris := r.watchedURIs(); len(uris) != 1 {
+		t.Fatalf("watching %v, want pihole://summary", uris)
+	}
+
+	// Disconnect without unsubscribing
+	session.Close()
+	if !waitFor(t, func() bool { return len(r.watchedURIs()) == 0 }) {
+		t.Errorf("still watching %v after the session closed", r.watchedURIs())
+	}
+}
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// schemaTypes holds the schemas of types whose JSON encoding differs from their Go structure
+var schemaTypes = map[reflect.Type]*jsonschema.Schema{
+	// UnixTime is marshaled through its embedded time.Time
+	reflect.TypeFor[client.UnixTime](): {Type: "string"},
+}
+
+// inputSchema generates the input schema of a tool from its argument struct.
+// Besides the description in the jsonschema tag, fields can carry default,
+// minimum, maximum, minItems and enum (comma-separated) tags. The instance argument is
+// described and restricted to the configured instances, and also accepts "all"
+// unless it is tagged fanOut:"false". write tells whether the tool changes
+// Pi-hole's state, which "all" then does on every instance.
+func (r *Registry) inputSchema(t reflect.Type, write bool) (*jsonschema.Schema, error) {
+	s, err := jsonschema.ForType(t, &jsonschema.ForOptions{TypeSchemas: schemaTypes})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		prop := s.Properties[name]
+		if prop == nil {
+			continue
+		}
+
+		if name == "instance" {
+			action, fanOut := "query", "query every instance and merge the results"
+			if write {
+				action, fanOut = "change", "apply the change to every instance"
+			}
+			prop.Description = fmt.Sprintf("Pi-hole instance to %s: one of %s", action, strings.Join(r.instances, ", "))
+			for _, inst := range r.instances {
+				prop.Enum = append(prop.Enum, inst)
+			}
+			if field.Tag.Get("fanOut") != "false" {
+				prop.Description += fmt.Sprintf(", or %q to %s", allInstances, fanOut)
+				prop.Enum = append(prop.Enum, allInstances)
+			}
+			prop.Description += fmt.Sprintf(" (default: %s)", r.instances[0])
+		}
+
+		if v, ok := field.Tag.Lookup("default"); ok {
+			if prop.Type == "string" {
+				v = strconv.Quote(v)
+			}
+			if !json.Valid([]byte(v)) {
+				return nil, fmt.Errorf("field %s: invalid default %q", field.Name, v)
+			}
+			prop.Default = json.RawMessage(v)
+		}
+		for tag, bound := range map[string]**float64{"minimum": &prop.Minimum, "maximum": &prop.Maximum} {
+			if v, ok := field.Tag.Lookup(tag); ok {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: invalid %s %q", field.Name, tag, v)
+				}
+				*bound = &f
+			}
+		}
+		if v, ok := field.Tag.Lookup("minItems"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: invalid minItems %q", field.Name, v)
+			}
+			prop.MinItems = &n
+		}
+		if v, ok := field.Tag.Lookup("enum"); ok {
+			// Enums on list arguments restrict their items
+			target := prop
+			if prop.Type == "array" {
+				target = prop.Items
+			}
+			for _, value := range strings.Split(v, ",") {
+				target.Enum = append(target.Enum, value)
+			}
+		}
+	}
+	return s, nil
+}
+
+// outputSchema generates the output schema of a tool from its result type.
+// encoding/json writes nil slices and maps as null, so those may be null.
+func outputSchema(t reflect.Type) (*jsonschema.Schema, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	s, err := jsonschema.ForType(t, &jsonschema.ForOptions{TypeSchemas: schemaTypes})
+	if err != nil {
+		return nil, err
+	}
+	for _, prop := range s.Properties {
+		allowNullCollections(prop)
+	}
+	return s, nil
+}
+
+// allowNullCollections lets the array and map schemas within s be null
+func allowNullCollections(s *jsonschema.Schema) {
+	if s == nil {
+		return
+	}
+	for _, prop := range s.Properties {
+		allowNullCollections(prop)
+	}
+	allowNullCollections(s.Items)
+	allowNullCollections(s.AdditionalProperties)
+
+	// Maps are objects without declared properties
+	if s.Type == "array" || (s.Type == "object" && s.Properties == nil) {
+		s.Types = []string{"null", s.Type}
+		s.Type = ""
+	}
+}
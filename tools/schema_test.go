@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInputSchema(t *testing.T) {
+	type input struct {
+		Name     string   `json:"name" jsonschema:"Name of the thing"`
+		Count    int      `json:"count,omitempty" default:"10" minimum:"1" maximum:"100"`
+		Kind     string   `json:"kind,omitempty" default:"exact" enum:"exact,regex"`
+		Tags     []string `json:"tags,omitempty" minItems:"1" enum:"a,b"`
+		Instance string   `json:"instance,omitempty"`
+	}
+	r := &Registry{instances: []string{"primary", "secondary"}}
+
+	s, err := r.inputSchema(reflect.TypeFor[input](), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(s.Required, []string{"name"}) {
+		t.Errorf("required = %v, want [name]", s.Required)
+	}
+	if got := s.Properties["name"].Description; got != "Name of the thing" {
+		t.Errorf("name description = %q", got)
+	}
+
+	count := s.Properties["count"]
+	if string(count.Default) != "10" || count.Minimum == nil || *count.Minimum != 1 || count.Maximum == nil || *count.Maximum != 100 {
+		t.Errorf("count = default %s, minimum %v, maximum %v", count.Default, count.Minimum, count.Maximum)
+	}
+
+	kind := s.Properties["kind"]
+	if string(kind.Default) != `"exact"` || !slices.Equal(kind.Enum, []any{"exact", "regex"}) {
+		t.Errorf("kind = default %s, enum %v", kind.Default, kind.Enum)
+	}
+
+	tags := s.Properties["tags"]
+	if tags.MinItems == nil || *tags.MinItems != 1 || !slices.Equal(tags.Items.Enum, []any{"a", "b"}) {
+		t.Errorf("tags = minItems %v, item enum %v", tags.MinItems, tags.Items.Enum)
+	}
+
+	instance := s.Properties["instance"]
+	if !slices.Equal(instance.Enum, []any{"primary", "secondary", "all"}) || !strings.Contains(instance.Description, "merge") {
+		t.Errorf("instance = enum %v, description %q", instance.Enum, instance.Description)
+	}
+}
+
+func TestInputSchemaInstance(t *testing.T) {
+	type single struct {
+		Instance string `json:"instance,omitempty" fanOut:"false"`
+	}
+	r := &Registry{instances: []string{"primary", "secondary"}}
+
+	tests := []struct {
+		name  string
+		t     reflect.Type
+		write bool
+		enum  []any
+		desc  string
+	}{
+		{"read", reflect.TypeFor[instanceInput](), false, []any{"primary", "secondary", "all"}, `or "all" to query every instance and merge the results`},
+		{"write", reflect.TypeFor[instanceInput](), true, []any{"primary", "secondary", "all"}, `or "all" to apply the change to every instance`},
+		{"single", reflect.TypeFor[single](), true, []any{"primary", "secondary"}, "Pi-hole instance to change: one of primary, secondary (default: primary)"},
+	}
+	for _, tt := range tests {
+		s, err := r.inputSchema(tt.t, tt.write)
+		if err != nil {
+			t.Fatal(err)
+		}
+		instance := s.Properties["instance"]
+		if !slices.Equal(instance.Enum, tt.enum) || !strings.Contains(instance.Description, tt.desc) {
+			t.Errorf("%s: instance = enum %v, description %q", tt.name, instance.Enum, instance.Description)
+		}
+	}
+}
+
+func TestOutputSchemaAllowsNullCollections(t *testing.T) {
+	type item struct {
+		Tags []string `json:"tags"`
+	}
+	type output struct {
+		Instances []string          `json:"instances"`
+		Errors    map[string]string `json:"errors,omitempty"`
+		Items     []item            `json:"items"`
+		Total     int               `json:"total"`
+	}
+
+	s, err := outputSchema(reflect.TypeFor[*output]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Type != "object" {
+		t.Fatalf("type = %q, want object", s.Type)
+	}
+
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range []map[string]any{
+		{"instances": nil, "items": nil, "total": 0},
+		{"instances": []any{"a"}, "errors": nil, "items": []any{map[string]any{"tags": nil}}, "total": 1},
+	} {
+		if err := resolved.Validate(doc); err != nil {
+			t.Errorf("Validate(%v) = %v", doc, err)
+		}
+	}
+	if err := resolved.Validate(map[string]any{"instances": nil, "items": nil, "total": nil}); err == nil {
+		t.Error("Validate accepted a null total")
+	}
+}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type queryLogInfo struct {
+	Time        string  `json:"time"`
+	Domain      string  `json:"domain"`
+	Type        string  `json:"type"`
+	Status      string  `json:"status"`
+	ClientIP    string  `json:"client_ip"`
+	ClientName  string  `json:"client_name,omitempty"`
+	Upstream    string  `json:"upstream,omitempty"`
+	ReplyType   string  `json:"reply_type,omitempty"`
+	ReplyTimeMs float64 `json:"reply_time_ms,omitempty"`
+	DNSSEC      string  `json:"dnssec,omitempty"`
+	CNAME       string  `json:"cname,omitempty"`
+	ListID      *int    `json:"list_id,omitempty"`
+	EDECode     int     `json:"ede_code,omitempty"`
+	EDEText     string  `json:"ede_text,omitempty"`
+	DatabaseID  int     `json:"id"`
+}
+
+type searchQueriesInput struct {
+	Domain   string `json:"domain,omitempty" jsonschema:"Domain to match; matches as a substring unless it contains '*' wildcards"`
+	Client   string `json:"client,omitempty" jsonschema:"Client IP address or hostname"`
+	Upstream string `json:"upstream,omitempty" jsonschema:"Upstream server the query was forwarded to (e.g. 1.1.1.1#53), or \"blocklist\"/\"cache\""`
+	Type     string `json:"type,omitempty" jsonschema:"Query type (e.g. A, AAAA, HTTPS, PTR)"`
+	Status   string `json:"status,omitempty" jsonschema:"Query status (e.g. GRAVITY, FORWARDED, CACHE, REGEX, DENYLIST)"`
+	Reply    string `json:"reply,omitempty" jsonschema:"Reply type (e.g. IP, NXDOMAIN, NODATA, CNAME, SERVFAIL)"`
+	DNSSEC   string `json:"dnssec,omitempty" jsonschema:"DNSSEC status (e.g. SECURE, INSECURE, BOGUS)"`
+	From     string `json:"from,omitempty" jsonschema:"Only queries at or after this RFC 3339 timestamp"`
+	Until    string `json:"until,omitempty" jsonschema:"Only queries before this RFC 3339 timestamp"`
+	Limit    int    `json:"limit,omitempty" jsonschema:"Maximum number of queries to return (default: 50, max: 500)" default:"50" minimum:"1" maximum:"500"`
+	Cursor   string `json:"cursor,omitempty" jsonschema:"next_cursor of a previous response, to continue with the same filters"`
+	Instance string `json:"instance,omitempty" fanOut:"false"`
+}
+
+type queryClientCount struct {
+	ClientIP   string `json:"client_ip"`
+	ClientName string `json:"client_name,omitempty"`
+	Count      int    `json:"count"`
+}
+
+type searchQueriesResponse struct {
+	Instance     string             `json:"instance"`
+	TotalMatches int                `json:"total_matches"`
+	Returned     int                `json:"returned"`
+	NextCursor   string             `json:"next_cursor,omitempty"`
+	Clients      []queryClientCount `json:"clients"`
+	Queries      []queryLogInfo     `json:"queries"`
+}
+
+// registerSearchQueries registers the tool for searching the query log
+func (r *Registry) registerSearchQueries(server *mcp.Server) {
+	addTool(r, server, &mcp.Tool{
+		Name:        "search_queries",
+		Description: "Search Pi-hole's query log, newest first, filtering by domain, client, upstream, query type, status, reply type, DNSSEC status and time range. Results are paged: pass next_cursor back as cursor to get the next page. Also summarizes which clients made the returned queries, e.g. to answer \"which devices hit doubleclick.net between 2 and 3am?\"",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleSearchQueries)
+}
+
+// handleSearchQueries handles requests for the search_queries tool
+func (r *Registry) handleSearchQueries(ctx context.Context, request *mcp.CallToolRequest, args searchQueriesInput) (*mcp.CallToolResult, *searchQueriesResponse, error) {
+	filter, err := buildQueryFilter(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Cursors are only meaningful on the Pi-hole that issued them
+	name, c, err := r.singleInstance(args.Instance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := c.SearchQueries(ctx, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to search queries: %v", err)
+	}
+
+	response := searchQueriesResponse{
+		Instance:     name,
+		TotalMatches: res.RecordsFiltered,
+		Returned:     len(res.Queries),
+		Clients:      []queryClientCount{},
+		Queries:      make([]queryLogInfo, 0, len(res.Queries)),
+	}
+	if next := filter.Start + len(res.Queries); len(res.Queries) > 0 && next < res.RecordsFiltered {
+		response.NextCursor = fmt.Sprintf("%d.%d", res.Cursor, next)
+	}
+
+	clientCounts := make(map[string]*queryClientCount)
+	for _, q := range res.Queries {
+		response.Queries = append(response.Queries, newQueryLogInfo(q))
+
+		count, ok := clientCounts[q.Client.Ip]
+		if !ok {
+			count = &queryClientCount{ClientIP: q.Client.Ip}
+			if q.Client.Name != nil {
+				count.ClientName = *q.Client.Name
+			}
+			clientCounts[q.Client.Ip] = count
+		}
+		count.Count++
+	}
+	for _, count := range clientCounts {
+		response.Clients = append(response.Clients, *count)
+	}
+	sort.Slice(response.Clients, func(i, j int) bool {
+		if response.Clients[i].Count != response.Clients[j].Count {
+			return response.Clients[i].Count > response.Clients[j].Count
+		}
+		return response.Clients[i].ClientIP < response.Clients[j].ClientIP
+	})
+
+	return nil, &response, nil
+}
+
+// buildQueryFilter validates the search arguments and turns them into a query log filter
+func buildQueryFilter(args searchQueriesInput) (client.QueryFilter, error) {
+	filter := client.QueryFilter{
+		Upstream: strings.TrimSpace(args.Upstream),
+		Type:     strings.ToUpper(strings.TrimSpace(args.Type)),
+		Status:   strings.ToUpper(strings.TrimSpace(args.Status)),
+		Reply:    strings.ToUpper(strings.TrimSpace(args.Reply)),
+		DNSSEC:   strings.ToUpper(strings.TrimSpace(args.DNSSEC)),
+		Length:   args.Limit,
+	}
+
+	// Pi-hole matches domains exactly unless the filter has wildcards
+	if domain := strings.ToLower(strings.TrimSpace(args.Domain)); domain != "" {
+		if !strings.Contains(domain, "*") {
+			domain = "*" + domain + "*"
+		}
+		filter.Domain = domain
+	}
+
+	if clientID := strings.TrimSpace(args.Client); clientID != "" {
+		if ip := net.ParseIP(clientID); ip != nil {
+			clientID = ip.String()
+		}
+		filter.Client = clientID
+	}
+
+	var err error
+	if args.From != "" {
+		if filter.From, err = time.Parse(time.RFC3339, args.From); err != nil {
+			return filter, fmt.Errorf("invalid from %q: expected an RFC 3339 timestamp", args.From)
+		}
+	}
+	if args.Until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, args.Until); err != nil {
+			return filter, fmt.Errorf("invalid until %q: expected an RFC 3339 timestamp", args.Until)
+		}
+	}
+	if !filter.From.IsZero() && !filter.Until.IsZero() && !filter.From.Before(filter.Until) {
+		return filter, fmt.Errorf("from must be before until")
+	}
+
+	// The in-memory log only covers the last 24 hours
+	filter.Disk = !filter.From.IsZero() && filter.From.Before(time.Now().Add(-client.HistoryWindow))
+
+	if args.Cursor != "" {
+		id, start, ok := strings.Cut(args.Cursor, ".")
+		filter.Cursor, err = strconv.Atoi(id)
+		if err == nil {
+			filter.Start, err = strconv.Atoi(start)
+		}
+		if !ok || err != nil || filter.Cursor < 0 || filter.Start < 0 {
+			return filter, fmt.Errorf("invalid cursor %q", args.Cursor)
+		}
+	}
+
+	return filter, nil
+}
+
+// newQueryLogInfo converts a query log entry for a tool response
+func newQueryLogInfo(q client.QueryLogEntry) queryLogInfo {
+	info := queryLogInfo{
+		Time:       time.Unix(int64(q.Time), 0).Format(time.RFC3339),
+		Domain:     q.Domain,
+		Type:       q.Type,
+		Status:     q.Status,
+		ClientIP:   q.Client.Ip,
+		ReplyType:  q.Reply.Type,
+		DNSSEC:     q.Dnssec,
+		ListID:     q.ListId,
+		EDECode:    q.Ede.Code,
+		DatabaseID: q.Id,
+	}
+	if q.Client.Name != nil {
+		info.ClientName = *q.Client.Name
+	}
+	if q.Upstream != nil {
+		info.Upstream = *q.Upstream
+	}
+	if q.Reply.Time > 0 {
+		info.ReplyTimeMs = q.Reply.Time * 1000
+	}
+	if q.Cname != nil {
+		info.CNAME = *q.Cname
+	}
+	if q.Ede.Text != nil {
+		info.EDEText = *q.Ede.Text
+	}
+	return info
+}
@@ -2,60 +2,209 @@ package tools
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ajinux/pi-hole-mcp-server/dnsclient"
 	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// Registry holds all available tools and the Pi-hole client
+// Registry holds all available tools and the Pi-hole clients
 type Registry struct {
-	piholeClient *client.Client
-	logger       *slog.Logger
+	clients map[string]*client.Client
+	// instances lists the instance names in configured order, the first one is the default
+	instances []string
+	logger    *slog.Logger
+
+	// resolver answers the DNS lookups of the domain intelligence tools
+	resolver *dnsclient.Resolver
+	// suffixes determines the registrable domain of looked up names
+	suffixes *dnsclient.SuffixList
+
+	// readOnly leaves out the tools that change Pi-hole's state
+	readOnly bool
+
+	// inflight counts tool calls and resource reads that are currently executing
+	inflight atomic.Int64
+
+	// subscriptions holds the resource URIs each session is subscribed to,
+	// resourceHashes the content hash of each watched resource at the last poll
+	subscriptionsMu sync.Mutex
+	subscriptions   map[*mcp.ServerSession]map[string]bool
+	resourceHashes  map[string][sha256.Size]byte
 }
 
-// NewRegistry creates a new tool registry with the given Pi-hole client
-func NewRegistry(piholeClient *client.Client, logger *slog.Logger) *Registry {
-	return &Registry{
-		piholeClient: piholeClient,
-		logger:       logger,
+// Instance is a named Pi-hole instance the registry can route tool calls to
+type Instance struct {
+	Name   string
+	Client *client.Client
+}
+
+// Bearer token scopes. Tokens need ScopeWrite to call tools that change Pi-hole's state.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// NewRegistry creates a new tool registry with the given Pi-hole instances.
+// The first instance is used when a tool call does not name one. A read-only
+// registry does not register tools that change Pi-hole's state. DNS record
+// lookups go through resolver, and suffixes (nil for the embedded list)
+// determines which registrable domain is looked up for a name.
+func NewRegistry(instances []Instance, resolver *dnsclient.Resolver, suffixes *dnsclient.SuffixList, readOnly bool, logger *slog.Logger) *Registry {
+	r := &Registry{
+		clients:        make(map[string]*client.Client, len(instances)),
+		logger:         logger,
+		resolver:       resolver,
+		suffixes:       suffixes,
+		readOnly:       readOnly,
+		subscriptions:  make(map[*mcp.ServerSession]map[string]bool),
+		resourceHashes: make(map[string][sha256.Size]byte),
+	}
+	for _, inst := range instances {
+		r.clients[inst.Name] = inst.Client
+		r.instances = append(r.instances, inst.Name)
 	}
+	return r
 }
 
-// RegisterAll registers all available tools with the MCP server
+// RegisterAll registers all available tools, resources and prompts with the MCP server
 func (r *Registry) RegisterAll(server *mcp.Server) {
+	r.registerNetworkSummary(server)
+	r.registerQueryHistory(server)
+	r.registerSearchQueries(server)
 	r.registerTopActiveClients(server)
 	r.registerTopDomainsForClient(server)
 	r.registerTopDomains(server)
 	r.registerDNSRecords(server)
 	r.registerWhoisLookup(server)
+	r.registerListAPISessions(server)
+	r.registerRevokeAPISession(server)
+	r.registerBlockDomain(server)
+	r.registerAllowDomain(server)
+	r.registerListDomainRules(server)
+	r.registerRemoveDomainRule(server)
+	r.registerExplainDomain(server)
+	r.registerGetBlockingStatus(server)
+	r.registerSetBlocking(server)
+	r.registerListGroups(server)
+	r.registerCreateGroup(server)
+	r.registerDeleteGroup(server)
+	r.registerUpdateGroup(server)
+	r.registerAssignClientToGroup(server)
+	r.registerRemoveClientFromGroup(server)
+	r.registerListAdlists(server)
+	r.registerAddAdlist(server)
+	r.registerSetAdlistEnabled(server)
+	r.registerRemoveAdlist(server)
+	r.registerUpdateGravity(server)
+	r.registerListLocalDNS(server)
+	r.registerAddLocalDNSRecord(server)
+	r.registerAddLocalCNAME(server)
+	r.registerRemoveLocalDNSRecord(server)
+
+	// Register resources
+	r.registerResources(server)
 
 	// Register prompts
 	r.registerDomainOSINTPrompt(server)
 }
 
-// ToolHandler is a function type for handling tool requests
-type ToolHandler func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error)
+// Drain blocks until all in-flight tool calls and resource reads have finished or ctx is done
+func (r *Registry) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for r.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// addTool registers a typed tool with logging and, for tools that change
+// Pi-hole's state, a scope check. Write tools are skipped on a read-only
+// registry. The input and output schemas are generated from In and Out.
+func addTool[In, Out any](r *Registry, server *mcp.Server, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out]) {
+	write := tool.Annotations == nil || !tool.Annotations.ReadOnlyHint
+	if write && r.readOnly {
+		return
+	}
+
+	var err error
+	if tool.InputSchema, err = r.inputSchema(reflect.TypeFor[In](), write); err != nil {
+		panic(fmt.Sprintf("tool %q: input schema: %v", tool.Name, err))
+	}
+	if tool.OutputSchema, err = outputSchema(reflect.TypeFor[Out]()); err != nil {
+		panic(fmt.Sprintf("tool %q: output schema: %v", tool.Name, err))
+	}
+
+	if write {
+		handler = requireWriteScope(handler)
+	}
+	mcp.AddTool(server, tool, withLogging(r, tool.Name, handler))
+}
+
+// requireWriteScope rejects calls authenticated with a bearer token that lacks ScopeWrite.
+// Calls without a token (stdio, or HTTP without auth tokens) are allowed.
+func requireWriteScope[In, Out any](handler mcp.ToolHandlerFor[In, Out]) mcp.ToolHandlerFor[In, Out] {
+	return func(ctx context.Context, request *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		if request.Extra != nil && request.Extra.TokenInfo != nil && !slices.Contains(request.Extra.TokenInfo.Scopes, ScopeWrite) {
+			var zero Out
+			return nil, zero, fmt.Errorf("%s changes Pi-hole's state and this token is read-only", request.Params.Name)
+		}
+		return handler(ctx, request, input)
+	}
+}
+
+// readOnlyAnnotations returns the annotations of a tool that only reads state.
+// openWorld marks tools that reach out beyond Pi-hole (DNS, WHOIS).
+func readOnlyAnnotations(openWorld bool) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		ReadOnlyHint:  true,
+		OpenWorldHint: &openWorld,
+	}
+}
+
+// writeAnnotations returns the annotations of a tool that changes Pi-hole's
+// state. destructive marks tools that remove or override existing state.
+func writeAnnotations(destructive, idempotent, openWorld bool) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		DestructiveHint: &destructive,
+		IdempotentHint:  idempotent,
+		OpenWorldHint:   &openWorld,
+	}
+}
+
+// withLogging wraps a typed tool handler with automatic logging. Arguments
+// rejected by the input schema never reach the handler and are not logged.
+func withLogging[In, Out any](r *Registry, toolName string, handler mcp.ToolHandlerFor[In, Out]) mcp.ToolHandlerFor[In, Out] {
+	return func(ctx context.Context, request *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		r.inflight.Add(1)
+		defer r.inflight.Add(-1)
 
-// withLogging wraps a tool handler with automatic logging
-func (r *Registry) withLogging(toolName string, handler ToolHandler) mcp.ToolHandler {
-	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		r.logger.Info("Tool invoked",
 			"tool", toolName,
 			"arguments", string(request.Params.Arguments),
 		)
 
-		result, err := handler(ctx, request)
+		result, output, err := handler(ctx, request, input)
 
 		if err != nil {
-			r.logger.Error("Tool handler error",
+			r.logger.Error("Tool execution failed",
 				"tool", toolName,
 				"error", err,
 			)
-			return result, err
-		}
-
-		if result != nil && result.IsError {
+		} else if result != nil && result.IsError {
 			r.logger.Error("Tool execution failed",
 				"tool", toolName,
 			)
@@ -65,6 +214,6 @@ func (r *Registry) withLogging(toolName string, handler ToolHandler) mcp.ToolHan
 			)
 		}
 
-		return result, err
+		return result, output, err
 	}
 }
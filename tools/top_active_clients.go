@@ -2,8 +2,9 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,85 +19,128 @@ type clientInfo struct {
 	MacAddress         []string `json:"mac_address"`
 	MacVendor          string   `json:"mac_vendor"`
 	LastRequestAgoMins int      `json:"last_request_ago_mins"`
+	Groups             []string `json:"groups,omitempty"`
+	Instances          []string `json:"instances,omitempty"`
 }
 
 type activeClientsResponse struct {
-	Clients []clientInfo `json:"clients"`
+	Instances      []string          `json:"instances"`
+	InstanceErrors map[string]string `json:"instance_errors,omitempty"`
+	Clients        []clientInfo      `json:"clients"`
+}
+
+// clientUsage is a client's activity as seen by a single Pi-hole instance
+type clientUsage struct {
+	info      clientInfo
+	lastQuery time.Time
+}
+
+type topActiveClientsInput struct {
+	Count    int    `json:"count,omitempty" jsonschema:"Number of top clients to return (default: 10)" default:"10" minimum:"1" maximum:"100"`
+	Instance string `json:"instance,omitempty"`
 }
 
 // registerTopActiveClients registers the tool for getting top active clients
 func (r *Registry) registerTopActiveClients(server *mcp.Server) {
-	server.AddTool(&mcp.Tool{
+	addTool(r, server, &mcp.Tool{
 		Name:        "get_top_active_clients",
-		Description: "Get the top N most active clients by DNS query usage from Pi-hole",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"count": map[string]interface{}{
-					"type":        "number",
-					"description": "Number of top clients to return (default: 10)",
-					"minimum":     1,
-					"maximum":     100,
-				},
-			},
-		},
-	}, r.withLogging("get_top_active_clients", r.handleTopActiveClients))
+		Description: "Get the top N most active clients by DNS query usage from Pi-hole, with the groups each client belongs to. When querying all instances, clients seen by several Pi-holes are merged by IP and their counts summed.",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleTopActiveClients)
 }
 
 // handleTopActiveClients handles requests for the get_top_active_clients tool
-func (r *Registry) handleTopActiveClients(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Parse arguments from JSON
-	var args struct {
-		Count int `json:"count"`
+func (r *Registry) handleTopActiveClients(ctx context.Context, request *mcp.CallToolRequest, args topActiveClientsInput) (*mcp.CallToolResult, *activeClientsResponse, error) {
+	// Call Pi-hole API on every targeted instance
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) ([]clientUsage, error) {
+		return r.topActiveClients(ctx, c, args.Count)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to get top active clients: %s", r.formatInstanceErrors(instanceErrors))
 	}
 
-	// Set default count
-	args.Count = 10
-
-	// Parse arguments if provided
-	if len(request.Params.Arguments) > 0 {
-		if err := json.Unmarshal(request.Params.Arguments, &args); err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{
-					&mcp.TextContent{
-						Text: fmt.Sprintf("Failed to parse arguments: %v", err),
-					},
-				},
-			}, nil
+	// Merge clients seen by several instances by IP
+	merged := make(map[string]*clientUsage)
+	var order []string
+	for _, res := range succeeded {
+		for _, usage := range res.Value {
+			existing, ok := merged[usage.info.Ip]
+			if !ok {
+				usage.info.Instances = []string{res.Instance}
+				merged[usage.info.Ip] = &usage
+				order = append(order, usage.info.Ip)
+				continue
+			}
+			existing.info.DnsRequestsCount += usage.info.DnsRequestsCount
+			existing.info.Instances = append(existing.info.Instances, res.Instance)
+			if existing.info.Name == "" {
+				existing.info.Name = usage.info.Name
+			}
+			if existing.info.MacVendor == "" {
+				existing.info.MacVendor = usage.info.MacVendor
+			}
+			for _, mac := range usage.info.MacAddress {
+				if !slices.Contains(existing.info.MacAddress, mac) {
+					existing.info.MacAddress = append(existing.info.MacAddress, mac)
+				}
+			}
+			for _, group := range usage.info.Groups {
+				if !slices.Contains(existing.info.Groups, group) {
+					existing.info.Groups = append(existing.info.Groups, group)
+				}
+			}
+			if usage.lastQuery.After(existing.lastQuery) {
+				existing.lastQuery = usage.lastQuery
+			}
 		}
 	}
 
-	// Validate count range
-	if args.Count < 1 {
-		args.Count = 1
-	} else if args.Count > 100 {
-		args.Count = 100
+	response := &activeClientsResponse{}
+	response.Instances = instanceNames(succeeded)
+	response.InstanceErrors = instanceErrors
+	for _, ip := range order {
+		usage := merged[ip]
+		if !usage.lastQuery.IsZero() {
+			usage.info.LastRequestAgoMins = int(time.Since(usage.lastQuery).Minutes())
+		}
+		// Only worth reporting where a client was seen when several instances were queried
+		if len(succeeded) == 1 {
+			usage.info.Instances = nil
+		}
+		response.Clients = append(response.Clients, usage.info)
 	}
 
-	// Call Pi-hole API
-	stats, err := r.piholeClient.GetTopActiveClientsByUsage(ctx, args.Count)
+	sort.SliceStable(response.Clients, func(i, j int) bool {
+		return response.Clients[i].DnsRequestsCount > response.Clients[j].DnsRequestsCount
+	})
+	if len(response.Clients) > args.Count {
+		response.Clients = response.Clients[:args.Count]
+	}
+
+	return nil, response, nil
+}
+
+// topActiveClients gets the most active clients of a single Pi-hole, enriched with MAC, vendor and group details
+func (r *Registry) topActiveClients(ctx context.Context, c *client.Client, count int) ([]clientUsage, error) {
+	stats, err := c.GetTopActiveClientsByUsage(ctx, count)
 	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to get top active clients: %v", err),
-				},
-			},
-		}, nil
+		return nil, err
 	}
 
-	allClients, err := r.piholeClient.GetAllClients(ctx)
+	allClients, err := c.GetAllClients(ctx)
 	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to get all clients: %v", err),
-				},
-			},
-		}, nil
+		return nil, fmt.Errorf("failed to get all clients: %w", err)
+	}
+
+	// Group membership is a nice to have, don't fail the whole call without it
+	membership, err := c.GetGroupMembership(ctx)
+	if err != nil {
+		r.logger.Warn("Failed to get group membership", "error", err)
 	}
 
 	// Map IPs to Names
@@ -106,43 +150,31 @@ func (r *Registry) handleTopActiveClients(ctx context.Context, request *mcp.Call
 	}
 
 	// Enrich stats with MAC and Names
-	var response activeClientsResponse
+	var usages []clientUsage
 	for _, clientStat := range stats.Clients {
-		clientInfo := clientInfo{
-			Ip:               clientStat.Ip,
-			Name:             clientStat.Name,
-			DnsRequestsCount: clientStat.Count,
+		usage := clientUsage{
+			info: clientInfo{
+				Ip:               clientStat.Ip,
+				Name:             clientStat.Name,
+				DnsRequestsCount: clientStat.Count,
+			},
 		}
 
 		if deviceInfo, exists := ipToDeviceInfo[clientStat.Ip]; exists {
-			clientInfo.MacAddress = strings.Split(deviceInfo.Hwaddr, ",")
-			clientInfo.MacVendor = deviceInfo.MacVendor
+			usage.info.MacAddress = strings.Split(deviceInfo.Hwaddr, ",")
+			usage.info.MacVendor = deviceInfo.MacVendor
 			if deviceInfo.LastQuery.Time.Unix() > 0 {
-				clientInfo.LastRequestAgoMins = int(time.Since(deviceInfo.LastQuery.Time).Minutes())
+				usage.lastQuery = deviceInfo.LastQuery.Time
 			}
 		}
 
-		response.Clients = append(response.Clients, clientInfo)
-	}
+		if membership != nil {
+			for _, g := range membership.GroupsFor(clientStat.Ip, usage.info.MacAddress, clientStat.Name) {
+				usage.info.Groups = append(usage.info.Groups, g.Name)
+			}
+		}
 
-	// Format response as JSON
-	resultJSON, err := json.MarshalIndent(response, "", "  ")
-	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to marshal response: %v", err),
-				},
-			},
-		}, nil
+		usages = append(usages, usage)
 	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return usages, nil
 }
@@ -2,15 +2,18 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"sort"
 
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type topDomainsGlobalResponse struct {
-	TotalDomains int          `json:"total_domains"`
-	Domains      []domainStat `json:"domains"`
+	Instances      []string          `json:"instances"`
+	InstanceErrors map[string]string `json:"instance_errors,omitempty"`
+	TotalDomains   int               `json:"total_domains"`
+	Domains        []domainStat      `json:"domains"`
 }
 
 type domainStat struct {
@@ -21,64 +24,62 @@ type domainStat struct {
 
 // registerTopDomains registers the tool for getting top queried domains globally
 func (r *Registry) registerTopDomains(server *mcp.Server) {
-	server.AddTool(&mcp.Tool{
+	addTool(r, server, &mcp.Tool{
 		Name:        "get_top_domains",
-		Description: "Get the top queried domains (both allowed and blocked) from Pi-hole",
-		InputSchema: map[string]interface{}{
-			"type":       "object",
-			"properties": map[string]interface{}{},
-		},
-	}, r.withLogging("get_top_domains", r.handleTopDomains))
+		Description: "Get the top queried domains (both allowed and blocked) from Pi-hole. When querying all instances, counts are summed across them.",
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleTopDomains)
 }
 
 // handleTopDomains handles requests for the get_top_domains tool
-func (r *Registry) handleTopDomains(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Get top domains from Pi-hole
-	domains, err := r.piholeClient.GetTopDomainsQueried(ctx)
+func (r *Registry) handleTopDomains(ctx context.Context, request *mcp.CallToolRequest, args instanceInput) (*mcp.CallToolResult, *topDomainsGlobalResponse, error) {
+	// Get top domains from every targeted Pi-hole
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) ([]*client.Domain, error) {
+		return c.GetTopDomainsQueried(ctx)
+	})
 	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to get top domains: %v", err),
-				},
-			},
-		}, nil
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to get top domains: %s", r.formatInstanceErrors(instanceErrors))
+	}
+
+	// Sum counts of the same domain across instances
+	type domainKey struct {
+		name    string
+		blocked bool
+	}
+	counts := make(map[domainKey]int)
+	for _, res := range succeeded {
+		for _, d := range res.Value {
+			counts[domainKey{d.Name, d.Blocked}] += d.Count
+		}
 	}
 
 	// Build response
-	var domainStats []domainStat
-	for _, d := range domains {
+	domainStats := make([]domainStat, 0, len(counts))
+	for key, count := range counts {
 		domainStats = append(domainStats, domainStat{
-			Domain:  d.Name,
-			Count:   d.Count,
-			Blocked: d.Blocked,
+			Domain:  key.name,
+			Count:   count,
+			Blocked: key.blocked,
 		})
 	}
+	sort.Slice(domainStats, func(i, j int) bool {
+		if domainStats[i].Count != domainStats[j].Count {
+			return domainStats[i].Count > domainStats[j].Count
+		}
+		return domainStats[i].Domain < domainStats[j].Domain
+	})
 
 	response := topDomainsGlobalResponse{
-		TotalDomains: len(domainStats),
-		Domains:      domainStats,
-	}
-
-	// Format response as JSON
-	resultJSON, err := json.MarshalIndent(response, "", "  ")
-	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to marshal response: %v", err),
-				},
-			},
-		}, nil
+		Instances:      instanceNames(succeeded),
+		InstanceErrors: instanceErrors,
+		TotalDomains:   len(domainStats),
+		Domains:        domainStats,
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return nil, &response, nil
 }
@@ -2,148 +2,115 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
 
+	"github.com/ajinux/pi-hole-mcp-server/pihole/client"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type domainInfo struct {
-	Domain        string `json:"domain"`
-	QueryCount    int    `json:"query_count"`
-	RejectedCount int    `json:"rejected_count"`
+	Domain        string                       `json:"domain"`
+	QueryCount    int                          `json:"query_count"`
+	RejectedCount int                          `json:"rejected_count"`
+	Categories    map[client.QueryCategory]int `json:"categories"`
 }
 
 type topDomainsResponse struct {
-	ClientIP        string       `json:"client_ip"`
-	HoursAnalyzed   int          `json:"hours_analyzed"`
-	TotalQueries    int          `json:"total_queries"`
-	RejectedQueries int          `json:"rejected_queries"`
-	Domains         []domainInfo `json:"domains"`
+	Instances       []string                     `json:"instances"`
+	InstanceErrors  map[string]string            `json:"instance_errors,omitempty"`
+	ClientIP        string                       `json:"client_ip"`
+	HoursAnalyzed   int                          `json:"hours_analyzed"`
+	TotalQueries    int                          `json:"total_queries"`
+	RejectedQueries int                          `json:"rejected_queries"`
+	Categories      map[client.QueryCategory]int `json:"categories"`
+	Domains         []domainInfo                 `json:"domains"`
+}
+
+// clientDomainCounts holds the query counts per domain and status category of
+// a client on one Pi-hole
+type clientDomainCounts map[string]map[client.QueryCategory]int
+
+type topDomainsForClientInput struct {
+	ClientIP string `json:"client_ip" jsonschema:"The IP address of the client"`
+	Hours    int    `json:"hours,omitempty" jsonschema:"Number of hours to look back (default: 24, max: 168 for 1 week)" default:"24" minimum:"1" maximum:"168"`
+	Count    int    `json:"count,omitempty" jsonschema:"Number of top domains to return (default: 10)" default:"10" minimum:"1" maximum:"100"`
+	Instance string `json:"instance,omitempty"`
 }
 
 // registerTopDomainsForClient registers the tool for getting top domains queried by a client
 func (r *Registry) registerTopDomainsForClient(server *mcp.Server) {
-	server.AddTool(&mcp.Tool{
+	addTool(r, server, &mcp.Tool{
 		Name:        "get_top_domains_for_client",
 		Description: "Get the top N most queried domains by a specific client IP address in the last X hours from Pi-hole",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"client_ip": map[string]interface{}{
-					"type":        "string",
-					"description": "The IP address of the client",
-				},
-				"hours": map[string]interface{}{
-					"type":        "number",
-					"description": "Number of hours to look back (default: 24, max: 168 for 1 week)",
-					"minimum":     1,
-					"maximum":     168,
-				},
-				"count": map[string]interface{}{
-					"type":        "number",
-					"description": "Number of top domains to return (default: 10)",
-					"minimum":     1,
-					"maximum":     100,
-				},
-			},
-			"required": []string{"client_ip"},
-		},
-	}, r.withLogging("get_top_domains_for_client", r.handleTopDomainsForClient))
+		Annotations: readOnlyAnnotations(false),
+	}, r.handleTopDomainsForClient)
 }
 
 // handleTopDomainsForClient handles requests for the get_top_domains_for_client tool
-func (r *Registry) handleTopDomainsForClient(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Parse arguments from JSON
-	var args struct {
-		ClientIP string  `json:"client_ip"`
-		Hours    float64 `json:"hours"`
-		Count    int     `json:"count"`
-	}
-
-	// Set defaults
-	args.Hours = 24
-	args.Count = 10
-
-	// Parse arguments
-	if len(request.Params.Arguments) > 0 {
-		if err := json.Unmarshal(request.Params.Arguments, &args); err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{
-					&mcp.TextContent{
-						Text: fmt.Sprintf("Failed to parse arguments: %v", err),
-					},
-				},
-			}, nil
-		}
-	}
-
+func (r *Registry) handleTopDomainsForClient(ctx context.Context, request *mcp.CallToolRequest, args topDomainsForClientInput) (*mcp.CallToolResult, *topDomainsResponse, error) {
 	// Validate client_ip is provided
 	if args.ClientIP == "" {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: "client_ip is required",
-				},
-			},
-		}, nil
-	}
-
-	// Validate hours range
-	if args.Hours < 1 {
-		args.Hours = 1
-	} else if args.Hours > 168 {
-		args.Hours = 168
-	}
-
-	// Validate count range
-	if args.Count < 1 {
-		args.Count = 1
-	} else if args.Count > 100 {
-		args.Count = 100
+		return nil, nil, errors.New("client_ip is required")
 	}
 
 	// Calculate time range
-	until := time.Now().Add(-time.Duration(args.Hours) * time.Hour)
-
-	// Get DNS queries for the client
-	queries, err := r.piholeClient.GetDNSQueriesForClient(ctx, args.ClientIP, until)
+	until := time.Now()
+	from := until.Add(-time.Duration(args.Hours) * time.Hour)
+
+	// Count the client's queries on every targeted Pi-hole as they are paged in
+	results, err := fanOut(ctx, r, args.Instance, func(ctx context.Context, _ string, c *client.Client) (*clientDomainCounts, error) {
+		counts := make(clientDomainCounts)
+		err := c.GetDNSQueriesForClient(ctx, args.ClientIP, from, until, func(query client.DNSQuery) error {
+			if counts[query.Domain] == nil {
+				counts[query.Domain] = make(map[client.QueryCategory]int)
+			}
+			counts[query.Domain][client.ClassifyStatus(query.Status)]++
+			return nil
+		})
+		return &counts, err
+	})
 	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to get DNS queries for client: %v", err),
-				},
-			},
-		}, nil
+		return nil, nil, err
+	}
+
+	succeeded, instanceErrors := splitResults(results)
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("Failed to get DNS queries for client: %s", r.formatInstanceErrors(instanceErrors))
 	}
 
 	// Aggregate domains
-	domainCounts := make(map[string]int)
-	domainRejectedCounts := make(map[string]int)
+	domainsByName := make(map[string]*domainInfo)
+	categories := make(map[client.QueryCategory]int)
 	rejectedCount := 0
-
-	for _, query := range queries {
-		domainCounts[query.Domain]++
-		if query.Status == "GRAVITY" {
-			rejectedCount++
-			domainRejectedCounts[query.Domain]++
+	totalQueries := 0
+
+	for _, res := range succeeded {
+		for domain, counts := range *res.Value {
+			info, ok := domainsByName[domain]
+			if !ok {
+				info = &domainInfo{Domain: domain, Categories: make(map[client.QueryCategory]int)}
+				domainsByName[domain] = info
+			}
+			for category, count := range counts {
+				info.Categories[category] += count
+				info.QueryCount += count
+				categories[category] += count
+				totalQueries += count
+				if category.Blocked() {
+					info.RejectedCount += count
+					rejectedCount += count
+				}
+			}
 		}
 	}
 
 	// Convert to slice and sort by count
 	var domains []domainInfo
-	for domain, count := range domainCounts {
-		domains = append(domains, domainInfo{
-			Domain:        domain,
-			QueryCount:    count,
-			RejectedCount: domainRejectedCounts[domain],
-		})
+	for _, info := range domainsByName {
+		domains = append(domains, *info)
 	}
 
 	sort.Slice(domains, func(i, j int) bool {
@@ -157,31 +124,15 @@ func (r *Registry) handleTopDomainsForClient(ctx context.Context, request *mcp.C
 
 	// Build response
 	response := topDomainsResponse{
+		Instances:       instanceNames(succeeded),
+		InstanceErrors:  instanceErrors,
 		ClientIP:        args.ClientIP,
-		HoursAnalyzed:   int(args.Hours),
-		TotalQueries:    len(queries),
+		HoursAnalyzed:   args.Hours,
+		TotalQueries:    totalQueries,
 		RejectedQueries: rejectedCount,
+		Categories:      categories,
 		Domains:         domains,
 	}
 
-	// Format response as JSON
-	resultJSON, err := json.MarshalIndent(response, "", "  ")
-	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to marshal response: %v", err),
-				},
-			},
-		}, nil
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return nil, &response, nil
 }
@@ -2,16 +2,14 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
-	"github.com/ajinux/pi-hole-mcp-server/dnsclient"
 	"github.com/ajinux/pi-hole-mcp-server/domain"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type whoisResponse struct {
-	Domain            string   `json:"domain"`
+	domainNames
 	Status            []string `json:"status,omitempty"`
 	NameServers       []string `json:"name_servers,omitempty"`
 	CreatedDate       string   `json:"created_date,omitempty"`
@@ -30,74 +28,29 @@ type whoisResponse struct {
 
 // registerWhoisLookup registers the tool for performing WHOIS lookups
 func (r *Registry) registerWhoisLookup(server *mcp.Server) {
-	server.AddTool(&mcp.Tool{
+	addTool(r, server, &mcp.Tool{
 		Name:        "get_domain_whois",
-		Description: "Perform a WHOIS lookup on a domain to get registration information (registrar, creation date, expiration date, registrant details, etc.). Automatically extracts top-level domain if a subdomain is provided.",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"domain": map[string]interface{}{
-					"type":        "string",
-					"description": "The domain or subdomain to query (e.g., example.com or api.example.com)",
-				},
-			},
-			"required": []string{"domain"},
-		},
-	}, r.withLogging("get_domain_whois", r.handleWhoisLookup))
+		Description: "Perform a WHOIS lookup on a domain to get registration information (registrar, creation date, expiration date, registrant details, etc.). Subdomains are resolved to their registrable domain using the Public Suffix List (e.g. foo.github.io stays foo.github.io, www.bbc.co.uk -> bbc.co.uk).",
+		Annotations: readOnlyAnnotations(true),
+	}, r.handleWhoisLookup)
 }
 
 // handleWhoisLookup handles requests for the get_domain_whois tool
-func (r *Registry) handleWhoisLookup(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Parse arguments from JSON
-	var args struct {
-		Domain string `json:"domain"`
-	}
-
-	// Parse arguments
-	if len(request.Params.Arguments) > 0 {
-		if err := json.Unmarshal(request.Params.Arguments, &args); err != nil {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{
-					&mcp.TextContent{
-						Text: fmt.Sprintf("Failed to parse arguments: %v", err),
-					},
-				},
-			}, nil
-		}
-	}
-
-	// Validate domain is provided
-	if args.Domain == "" {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: "domain is required",
-				},
-			},
-		}, nil
+func (r *Registry) handleWhoisLookup(ctx context.Context, request *mcp.CallToolRequest, args domainInput) (*mcp.CallToolResult, *whoisResponse, error) {
+	names, err := r.registrableDomain(args.Domain)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Strip to top-level domain
-	tld := dnsclient.ExtractTopLevelDomain(args.Domain)
-
 	// Perform WHOIS lookup
-	whoisInfo, err := domain.Whois(tld)
+	whoisInfo, err := domain.Whois(names.RegistrableDomain)
 	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to perform WHOIS lookup: %v", err),
-				},
-			},
-		}, nil
+		return nil, nil, fmt.Errorf("Failed to perform WHOIS lookup: %v", err)
 	}
 
 	// Extract important OSINT fields with nil checks
 	response := whoisResponse{
-		Domain: tld,
+		domainNames: names,
 	}
 
 	// Domain information
@@ -134,24 +87,5 @@ func (r *Registry) handleWhoisLookup(ctx context.Context, request *mcp.CallToolR
 		response.BillingEmail = whoisInfo.Billing.Email
 	}
 
-	// Format response as JSON
-	resultJSON, err := json.MarshalIndent(response, "", "  ")
-	if err != nil {
-		return &mcp.CallToolResult{
-			IsError: true,
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Failed to marshal response: %v", err),
-				},
-			},
-		}, nil
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return nil, &response, nil
 }